@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/getsynq/synq-sqlmesh/git"
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/sirupsen/logrus"
+)
+
+var ChangedSince = ""
+var ChangedDownstream = false
+var ImpactReportFile = ""
+
+// changedFiles returns nil when collection is not limited to changed files.
+func changedFiles(ctx context.Context) ([]string, error) {
+	if ChangedSince == "" {
+		return nil, nil
+	}
+	files, err := git.ChangedFiles(ctx, SQLMeshProjectDir, ChangedSince)
+	if err != nil {
+		return nil, withExitCode(ExitConfigError, fmt.Errorf("failed to find files changed since %s: %w", ChangedSince, err))
+	}
+	logrus.Infof("Found %d files changed since %s", len(files), ChangedSince)
+	return files, nil
+}
+
+// reportImpact logs the impact of the changes and writes the report to
+// --impact-report, as JSON when the file name ends with `.json` and as
+// Markdown otherwise.
+func reportImpact(report *sqlmesh.ImpactReport) error {
+	logrus.Infof("Changes since %s affect %d models and %d downstream models", report.ChangedSince, len(report.ChangedModels), len(report.DownstreamModels))
+	for _, modelName := range report.ChangedModels {
+		logrus.Infof("Changed model %s", modelName)
+	}
+	for _, model := range report.DownstreamModels {
+		logrus.Infof("Downstream model %s", model.Name)
+	}
+	if len(report.UnmappedFiles) > 0 {
+		logrus.Warnf("%d changed files define no model and may affect further models", len(report.UnmappedFiles))
+	}
+
+	if ImpactReportFile == "" {
+		return nil
+	}
+	w := os.Stdout
+	if ImpactReportFile != "-" {
+		f, err := os.Create(ImpactReportFile)
+		if err != nil {
+			return withExitCode(ExitOutputFailure, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if filepath.Ext(ImpactReportFile) == ".json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return withExitCode(ExitOutputFailure, err)
+		}
+		return nil
+	}
+	if err := report.WriteMarkdown(w); err != nil {
+		return withExitCode(ExitOutputFailure, err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&ChangedSince, "changed-since", ChangedSince, "Collect only models defined in files changed since the git ref, e.g. origin/main")
+	rootCmd.PersistentFlags().BoolVar(&ChangedDownstream, "changed-downstream", ChangedDownstream, "With --changed-since collect also models downstream of changed models")
+	rootCmd.PersistentFlags().StringVar(&ImpactReportFile, "impact-report", ImpactReportFile, "File the impact report of --changed-since is written to, Markdown or JSON by .json extension, - for stdout")
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings are resolved with precedence flag > environment > per-project
+// section of the config file > config file > default. Config file keys are
+// the flag names, nested maps are joined with `-`, so `sqlmesh: {ui: {port: 8081}}`
+// sets `--sqlmesh-ui-port`. Environment variables are named
+// `SYNQ_SQLMESH_<FLAG>` with the `sqlmesh-` prefix dropped, e.g.
+// `SYNQ_SQLMESH_UI_PORT` or `SYNQ_SQLMESH_SYNQ_ENDPOINT`.
+
+const defaultConfigFileName = "synq-sqlmesh.yaml"
+
+var ConfigFile string
+var ConfigProject string
+
+// configSources records where the effective value of every setting came from.
+var configSources = map[string]string{}
+
+// flags which configure the configuration loading itself.
+var configMetaFlags = map[string]bool{"config": true, "project": true, "help": true}
+
+var secretFlags = map[string]bool{"synq-token": true}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect synq-sqlmesh configuration",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print effective configuration and where each value came from",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+		for _, name := range configurableFlagNames(rootCmd.PersistentFlags()) {
+			flag := rootCmd.PersistentFlags().Lookup(name)
+			value := flag.Value.String()
+			if secretFlags[name] && value != "" {
+				value = "<redacted>"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", name, value, configSources[name])
+		}
+		_ = w.Flush()
+	},
+}
+
+func EnvVarName(flagName string) string {
+	name := strings.TrimPrefix(flagName, "sqlmesh-")
+	return "SYNQ_SQLMESH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// legacyEnvVars are environment variables read before the SYNQ_SQLMESH_*
+// mapping existed, they are used when the mapped variable is not set.
+var legacyEnvVars = map[string]string{"synq-token": "SYNQ_TOKEN"}
+
+// lookupEnv returns the environment variable setting the flag.
+func lookupEnv(flagName string) (string, string, bool) {
+	for _, envName := range []string{EnvVarName(flagName), legacyEnvVars[flagName]} {
+		if envName == "" {
+			continue
+		}
+		if value := os.Getenv(envName); value != "" {
+			return envName, value, true
+		}
+	}
+	return "", "", false
+}
+
+func configurableFlagNames(flags *pflag.FlagSet) []string {
+	var names []string
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if !configMetaFlags[flag.Name] {
+			names = append(names, flag.Name)
+		}
+	})
+	sort.Strings(names)
+	return names
+}
+
+// loadConfig applies config file and environment values to every
+// persistent flag which was not set on the command line.
+func loadConfig() error {
+	return applyConfig(rootCmd.PersistentFlags())
+}
+
+func applyConfig(flags *pflag.FlagSet) error {
+	if !flags.Changed("config") {
+		if _, value, ok := lookupEnv("config"); ok {
+			ConfigFile = value
+		}
+	}
+	if !flags.Changed("project") {
+		if _, value, ok := lookupEnv("project"); ok {
+			ConfigProject = value
+		}
+	}
+
+	configFile := ConfigFile
+	if configFile == "" {
+		projectDir := flags.Lookup("sqlmesh-project-dir").Value.String()
+		if _, value, ok := lookupEnv("sqlmesh-project-dir"); ok && !flags.Changed("sqlmesh-project-dir") {
+			projectDir = value
+		}
+		for _, candidate := range []string{defaultConfigFileName, "synq-sqlmesh.yml"} {
+			candidatePath := filepath.Join(projectDir, candidate)
+			if _, err := os.Stat(candidatePath); err == nil {
+				configFile = candidatePath
+				break
+			}
+		}
+	}
+
+	fileValues := map[string]string{}
+	projectValues := map[string]string{}
+	if configFile != "" {
+		var err error
+		fileValues, projectValues, err = readConfigFile(flags, configFile, ConfigProject)
+		if err != nil {
+			return withExitCode(ExitConfigError, err)
+		}
+	} else if ConfigProject != "" {
+		return withExitCode(ExitConfigError, fmt.Errorf("project %s selected but no config file found", ConfigProject))
+	}
+
+	for _, name := range configurableFlagNames(flags) {
+		flag := flags.Lookup(name)
+
+		if flag.Changed {
+			configSources[name] = "flag --" + name
+			continue
+		}
+		if envName, value, ok := lookupEnv(name); ok {
+			if err := flag.Value.Set(value); err != nil {
+				return withExitCode(ExitConfigError, fmt.Errorf("invalid value of %s: %w", envName, err))
+			}
+			configSources[name] = "env " + envName
+			continue
+		}
+
+		if value, ok := projectValues[name]; ok {
+			if err := flag.Value.Set(value); err != nil {
+				return withExitCode(ExitConfigError, fmt.Errorf("invalid value of %s in %s project %s: %w", name, configFile, ConfigProject, err))
+			}
+			configSources[name] = fmt.Sprintf("config %s (project %s)", configFile, ConfigProject)
+		} else if value, ok := fileValues[name]; ok {
+			if err := flag.Value.Set(value); err != nil {
+				return withExitCode(ExitConfigError, fmt.Errorf("invalid value of %s in %s: %w", name, configFile, err))
+			}
+			configSources[name] = "config " + configFile
+		} else {
+			configSources[name] = "default"
+		}
+	}
+
+	return nil
+}
+
+func readConfigFile(flags *pflag.FlagSet, configFile string, project string) (map[string]string, map[string]string, error) {
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+
+	projects, _ := raw["projects"].(map[string]interface{})
+	delete(raw, "projects")
+
+	fileValues := map[string]string{}
+	if err := flattenConfig(flags, configFile, "", raw, fileValues); err != nil {
+		return nil, nil, err
+	}
+
+	projectValues := map[string]string{}
+	if project != "" {
+		projectRaw, ok := projects[project].(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: project %s not found", configFile, project)
+		}
+		if err := flattenConfig(flags, configFile, "", projectRaw, projectValues); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return fileValues, projectValues, nil
+}
+
+func flattenConfig(flags *pflag.FlagSet, configFile string, prefix string, raw map[string]interface{}, values map[string]string) error {
+	for key, value := range raw {
+		name := strings.ReplaceAll(key, "_", "-")
+		if prefix != "" {
+			name = prefix + "-" + name
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flattenConfig(flags, configFile, name, v, values); err != nil {
+				return err
+			}
+			continue
+		case []interface{}:
+			var items []string
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[name] = strings.Join(items, ",")
+		case nil:
+			continue
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+		if configMetaFlags[name] || flags.Lookup(name) == nil {
+			return fmt.Errorf("%s: unknown setting %s", configFile, name)
+		}
+	}
+	return nil
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+const testConfigFile = `synq:
+  endpoint: https://file.synq.io/
+  token: file-token
+sqlmesh:
+  ui:
+    port: 8081
+projects:
+  shop:
+    sqlmesh_ui_port: 8082
+`
+
+func newTestFlags(t *testing.T, dir string) *pflag.FlagSet {
+	t.Helper()
+	configFile, configProject, sources := ConfigFile, ConfigProject, configSources
+	t.Cleanup(func() { ConfigFile, ConfigProject, configSources = configFile, configProject, sources })
+	ConfigFile, ConfigProject, configSources = "", "", map[string]string{}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVar(&ConfigFile, "config", "", "")
+	flags.StringVar(&ConfigProject, "project", "", "")
+	flags.String("sqlmesh-project-dir", dir, "")
+	flags.String("synq-endpoint", "https://developer.synq.io/", "")
+	flags.String("synq-token", "", "")
+	flags.Int("sqlmesh-ui-port", 8080, "")
+	flags.Bool("strict", false, "")
+	return flags
+}
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		env     map[string]string
+		args    []string
+		setting string
+		value   string
+		source  string
+	}{
+		{name: "default", setting: "strict", value: "false", source: "default"},
+		{name: "config file", setting: "synq-endpoint", value: "https://file.synq.io/", source: "config"},
+		{name: "nested config file", setting: "sqlmesh-ui-port", value: "8081", source: "config"},
+		{name: "project over config file", project: "shop", setting: "sqlmesh-ui-port", value: "8082", source: "config"},
+		{
+			name: "env over project", project: "shop",
+			env:     map[string]string{"SYNQ_SQLMESH_UI_PORT": "8083"},
+			setting: "sqlmesh-ui-port", value: "8083", source: "env SYNQ_SQLMESH_UI_PORT",
+		},
+		{
+			name:    "flag over env",
+			env:     map[string]string{"SYNQ_SQLMESH_UI_PORT": "8083"},
+			args:    []string{"--sqlmesh-ui-port", "8084"},
+			setting: "sqlmesh-ui-port", value: "8084", source: "flag --sqlmesh-ui-port",
+		},
+		{name: "token from config file", setting: "synq-token", value: "file-token", source: "config"},
+		{
+			name:    "SYNQ_TOKEN over config file",
+			env:     map[string]string{"SYNQ_TOKEN": "env-token"},
+			setting: "synq-token", value: "env-token", source: "env SYNQ_TOKEN",
+		},
+		{
+			name:    "mapped env over SYNQ_TOKEN",
+			env:     map[string]string{"SYNQ_TOKEN": "env-token", "SYNQ_SQLMESH_SYNQ_TOKEN": "mapped-token"},
+			setting: "synq-token", value: "mapped-token", source: "env SYNQ_SQLMESH_SYNQ_TOKEN",
+		},
+		{
+			name:    "flag over SYNQ_TOKEN",
+			env:     map[string]string{"SYNQ_TOKEN": "env-token"},
+			args:    []string{"--synq-token", "flag-token"},
+			setting: "synq-token", value: "flag-token", source: "flag --synq-token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"SYNQ_TOKEN", "SYNQ_SQLMESH_SYNQ_TOKEN", "SYNQ_SQLMESH_UI_PORT", "SYNQ_SQLMESH_SYNQ_ENDPOINT", "SYNQ_SQLMESH_CONFIG", "SYNQ_SQLMESH_PROJECT", "SYNQ_SQLMESH_PROJECT_DIR", "SYNQ_SQLMESH_STRICT"} {
+				t.Setenv(name, "")
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			dir := t.TempDir()
+			configFile := filepath.Join(dir, defaultConfigFileName)
+			if err := os.WriteFile(configFile, []byte(testConfigFile), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			flags := newTestFlags(t, dir)
+			args := tt.args
+			if tt.project != "" {
+				args = append(args, "--project", tt.project)
+			}
+			if err := flags.Parse(args); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := applyConfig(flags); err != nil {
+				t.Fatal(err)
+			}
+			if value := flags.Lookup(tt.setting).Value.String(); value != tt.value {
+				t.Errorf("expected %s, got %s", tt.value, value)
+			}
+			source := tt.source
+			if source == "config" {
+				source = "config " + configFile
+				if tt.project != "" {
+					source += " (project " + tt.project + ")"
+				}
+			}
+			if configSources[tt.setting] != source {
+				t.Errorf("expected source %s, got %s", source, configSources[tt.setting])
+			}
+		})
+	}
+}
+
+func TestApplyConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		args    []string
+		env     map[string]string
+	}{
+		{name: "unknown setting", content: "sqlmesh_ui_host: localhost\n"},
+		{name: "invalid value in file", content: "sqlmesh_ui_port: high\n"},
+		{name: "unknown project", content: "synq_endpoint: https://file.synq.io/\n", args: []string{"--project", "missing"}},
+		{name: "invalid env value", content: "", env: map[string]string{"SYNQ_SQLMESH_UI_PORT": "high"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SYNQ_SQLMESH_UI_PORT", "")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, defaultConfigFileName), []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			flags := newTestFlags(t, dir)
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			err := applyConfig(flags)
+			if ExitCode(err) != ExitConfigError {
+				t.Errorf("expected a configuration error, got %v", err)
+			}
+		})
+	}
+}
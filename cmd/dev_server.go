@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/getsynq/synq-sqlmesh/devserver"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+)
+
+var devServerCmd = &cobra.Command{
+	Use:   "dev-server",
+	Short: "Run local fake SYNQ ingest API recording received requests",
+	Long: "Run local fake SYNQ ingest API recording received requests. Point other commands to it with " +
+		"`--synq-endpoint http://<listen>/`, requests are stored in the output directory.",
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		var failCode codes.Code
+		if err := failCode.UnmarshalJSON([]byte(`"` + strings.ToUpper(DevServerFailCode) + `"`)); err != nil {
+			logrus.WithError(err).Error("Invalid gRPC status code")
+			exit(withExitCode(ExitConfigError, err))
+		}
+
+		server := devserver.New(devserver.Options{
+			OutputDir:   DevServerOutputDir,
+			Token:       DevServerToken,
+			RejectToken: DevServerRejectToken,
+			FailFirst:   DevServerFailFirst,
+			FailCode:    failCode,
+			Delay:       DevServerDelay,
+		})
+		if err := server.Start(DevServerListen); err != nil {
+			logrus.WithError(err).Error("Failed to start dev server")
+			exit(withExitCode(ExitConfigError, err))
+		}
+		defer server.Close()
+		logrus.Infof("Dev server listening, use --synq-endpoint %s", server.URL())
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+	},
+}
+
+var DevServerListen = "localhost:8090"
+var DevServerOutputDir = "synq-dev-server"
+var DevServerToken string
+var DevServerRejectToken bool
+var DevServerFailFirst int
+var DevServerFailCode = "UNAVAILABLE"
+var DevServerDelay time.Duration
+
+func init() {
+	devServerCmd.Flags().StringVar(&DevServerListen, "listen", DevServerListen, "Address to listen on")
+	devServerCmd.Flags().StringVar(&DevServerOutputDir, "output-dir", DevServerOutputDir, "Directory where received requests are recorded")
+	devServerCmd.Flags().StringVar(&DevServerToken, "token", DevServerToken, "Accept only this SYNQ token, any token is accepted if empty")
+	devServerCmd.Flags().BoolVar(&DevServerRejectToken, "reject-token", DevServerRejectToken, "Reject every token exchange")
+	devServerCmd.Flags().IntVar(&DevServerFailFirst, "fail-first", DevServerFailFirst, "Fail the first N ingest requests")
+	devServerCmd.Flags().StringVar(&DevServerFailCode, "fail-code", DevServerFailCode, "gRPC status code of injected failures")
+	devServerCmd.Flags().DurationVar(&DevServerDelay, "delay", DevServerDelay, "Delay applied to every ingest request")
+
+	rootCmd.AddCommand(devServerCmd)
+}
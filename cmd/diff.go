@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/getsynq/synq-sqlmesh/synq"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var DiffJson = false
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <before> <after>",
+	Short: "Compare two metadata dumps created by `collect`",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		before, err := loadMetadataSnapshot(args[0])
+		if err != nil {
+			fmt.Println(err)
+			exit(withExitCode(ExitConfigError, err))
+		}
+		after, err := loadMetadataSnapshot(args[1])
+		if err != nil {
+			fmt.Println(err)
+			exit(withExitCode(ExitConfigError, err))
+		}
+
+		diff := sqlmesh.Diff(before, after)
+		if DiffJson {
+			asJson, _ := json.MarshalIndent(diff, "", "  ")
+			fmt.Println(string(asJson))
+			return
+		}
+		printDiff(diff)
+	},
+}
+
+func loadMetadataSnapshot(filename string) (*sqlmesh.Metadata, error) {
+	output, err := synq.LoadMetadata(filename)
+	if err != nil {
+		return nil, err
+	}
+	metadata, errs := sqlmesh.DecodeMetadata(output)
+	for _, err := range errs {
+		logrus.Warnf("%s: %s", filename, err)
+	}
+	return metadata, nil
+}
+
+func printDiff(diff *sqlmesh.MetadataDiff) {
+	if diff.Empty() {
+		fmt.Println("No differences")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHANGE\tSUBJECT\tDETAILS")
+	for _, modelName := range diff.AddedModels {
+		fmt.Fprintf(w, "+ model\t%s\t\n", modelName)
+	}
+	for _, modelName := range diff.RemovedModels {
+		fmt.Fprintf(w, "- model\t%s\t\n", modelName)
+	}
+	for _, model := range diff.ChangedModels {
+		for _, change := range model.Changes {
+			switch change.Change {
+			case sqlmesh.ColumnAdded:
+				fmt.Fprintf(w, "~ model\t%s\t%s: added %s\n", model.Name, change.Field, orNone(change.New))
+			case sqlmesh.ColumnRemoved:
+				fmt.Fprintf(w, "~ model\t%s\t%s: removed %s\n", model.Name, change.Field, orNone(change.Old))
+			default:
+				fmt.Fprintf(w, "~ model\t%s\t%s: %s -> %s\n", model.Name, change.Field, orNone(change.Old), orNone(change.New))
+			}
+		}
+	}
+	for _, edge := range diff.AddedEdges {
+		fmt.Fprintf(w, "+ lineage\t%s\t<- %s\n", edge.Model, edge.Upstream)
+	}
+	for _, edge := range diff.RemovedEdges {
+		fmt.Fprintf(w, "- lineage\t%s\t<- %s\n", edge.Model, edge.Upstream)
+	}
+	for _, file := range diff.ChangedFiles {
+		prefix := "~"
+		switch file.Change {
+		case sqlmesh.FileAdded:
+			prefix = "+"
+		case sqlmesh.FileRemoved:
+			prefix = "-"
+		}
+		fmt.Fprintf(w, "%s file\t%s\t%s\n", prefix, file.Path, file.Change)
+	}
+	_ = w.Flush()
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&DiffJson, "json", DiffJson, "Print differences as JSON")
+
+	rootCmd.AddCommand(diffCmd)
+}
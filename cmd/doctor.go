@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/getsynq/synq-sqlmesh/git"
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/getsynq/synq-sqlmesh/synq"
+	"github.com/spf13/cobra"
+)
+
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+	CheckSkip = "skip"
+)
+
+type DoctorCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+var DoctorJson = false
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the environment used to collect and upload SQLMesh metadata",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		checks := runDoctorChecks(cmd.Context())
+
+		if DoctorJson {
+			asJson, _ := json.MarshalIndent(checks, "", "  ")
+			fmt.Println(string(asJson))
+		} else {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CHECK\tSTATUS\tDETAILS")
+			for _, check := range checks {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", check.Name, strings.ToUpper(check.Status), check.Message)
+			}
+			_ = w.Flush()
+		}
+
+		if err := doctorResult(checks); err != nil {
+			exit(err)
+		}
+	},
+}
+
+// doctorResult fails with ExitCheckFailed, also without `--strict`, when any
+// check failed. Warnings and skipped checks don't fail.
+func doctorResult(checks []DoctorCheck) error {
+	failed := 0
+	for _, check := range checks {
+		if check.Status == CheckFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return withExitCode(ExitCheckFailed, fmt.Errorf("%d checks failed", failed))
+	}
+	return nil
+}
+
+func runDoctorChecks(ctx context.Context) []DoctorCheck {
+	var checks []DoctorCheck
+
+	sqlMeshPath, err := exec.LookPath(SQLMesh)
+	if err != nil {
+		checks = append(checks, DoctorCheck{"sqlmesh", CheckFail, fmt.Sprintf("%s not found, install SQLMesh or use --sqlmesh-cmd: %s", SQLMesh, err)})
+		checks = append(checks, DoctorCheck{"sqlmesh-web", CheckSkip, "sqlmesh not found"})
+		checks = append(checks, DoctorCheck{"sqlmesh-version", CheckSkip, "sqlmesh not found"})
+	} else {
+		checks = append(checks, DoctorCheck{"sqlmesh", CheckPass, sqlMeshPath})
+		checks = append(checks, checkSQLMeshWeb(ctx, sqlMeshPath))
+		checks = append(checks, checkSQLMeshVersion(ctx, sqlMeshPath))
+	}
+
+	checks = append(checks, checkUiPort())
+	checks = append(checks, checkGitContext(ctx))
+	checks = append(checks, checkSynq(ctx)...)
+
+	return checks
+}
+
+// checkSQLMeshWeb imports modules of the `web` extra with the interpreter of
+// the sqlmesh launcher script.
+func checkSQLMeshWeb(ctx context.Context, sqlMeshPath string) DoctorCheck {
+	python := launcherInterpreter(sqlMeshPath)
+	if python == "" {
+		return DoctorCheck{"sqlmesh-web", CheckWarn, "could not determine Python interpreter of sqlmesh, make sure `sqlmesh[web]` is installed"}
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, python, "-c", "import fastapi, uvicorn").CombinedOutput()
+	if err != nil {
+		return DoctorCheck{"sqlmesh-web", CheckFail, fmt.Sprintf("web extra missing, run `pip install \"sqlmesh[web]\"`: %s", strings.TrimSpace(string(out)))}
+	}
+	return DoctorCheck{"sqlmesh-web", CheckPass, "web extra installed"}
+}
+
+func launcherInterpreter(sqlMeshPath string) string {
+	f, err := os.Open(sqlMeshPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+	if filepath.Base(fields[0]) == "env" && len(fields) > 1 {
+		return fields[1]
+	}
+	if !strings.Contains(filepath.Base(fields[0]), "python") {
+		return ""
+	}
+	return fields[0]
+}
+
+func checkSQLMeshVersion(ctx context.Context, sqlMeshPath string) DoctorCheck {
+	version, err := sqlMeshCliVersion(ctx, sqlMeshPath)
+	if err != nil {
+		return DoctorCheck{"sqlmesh-version", CheckFail, err.Error()}
+	}
+	compatibility := sqlmesh.CompatibilityFor(version)
+	switch compatibility.Status {
+	case sqlmesh.CompatibilitySupported:
+		return DoctorCheck{"sqlmesh-version", CheckPass, version.String()}
+	case sqlmesh.CompatibilityUnsupported:
+		return DoctorCheck{"sqlmesh-version", CheckFail, fmt.Sprintf("%s: %s", version, compatibility.Note)}
+	default:
+		return DoctorCheck{"sqlmesh-version", CheckWarn, fmt.Sprintf("%s: %s", version, compatibility.Note)}
+	}
+}
+
+func checkUiPort() DoctorCheck {
+	addr := net.JoinHostPort(SQLMeshUiHost, fmt.Sprintf("%d", SQLMeshUiPort))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		if SQLMeshUiStart {
+			return DoctorCheck{"sqlmesh-ui-port", CheckFail, fmt.Sprintf("%s is not available, use --sqlmesh-ui-port: %s", addr, err)}
+		}
+		return DoctorCheck{"sqlmesh-ui-port", CheckPass, fmt.Sprintf("%s in use by externally managed UI", addr)}
+	}
+	_ = listener.Close()
+	if !SQLMeshUiStart {
+		return DoctorCheck{"sqlmesh-ui-port", CheckFail, fmt.Sprintf("nothing listens on %s but --sqlmesh-ui-start=false", addr)}
+	}
+	return DoctorCheck{"sqlmesh-ui-port", CheckPass, fmt.Sprintf("%s is free", addr)}
+}
+
+func checkGitContext(ctx context.Context) DoctorCheck {
+	gitDetails := git.CollectGitDetails(ctx, SQLMeshProjectDir, git.WithRemote(GitRemote))
+	if gitDetails == nil {
+		return DoctorCheck{"git", CheckWarn, "git is not available, uploads will have no git context"}
+	}
+	if gitDetails.CommitSha == "" {
+		return DoctorCheck{"git", CheckWarn, fmt.Sprintf("%s is not a git repository", SQLMeshProjectDir)}
+	}
+	message := fmt.Sprintf("%s@%s (%s)", gitDetails.CloneUrl, gitDetails.Branch, gitDetails.CommitSha)
+	if gitDetails.CI != nil {
+		message += fmt.Sprintf(", CI %s", gitDetails.CI.Provider)
+	}
+	if gitDetails.Dirty {
+		return DoctorCheck{"git", CheckWarn, message + ", work tree has uncommitted changes"}
+	}
+	return DoctorCheck{"git", CheckPass, message}
+}
+
+func checkSynq(ctx context.Context) []DoctorCheck {
+	var checks []DoctorCheck
+
+	if SynqApiToken == "" {
+		checks = append(checks, DoctorCheck{"synq-token", CheckFail, "SYNQ_TOKEN environment variable is not set"})
+	} else if err := synq.CheckToken(SynqApiEndpoint, SynqApiToken, synqUploadOpts()...); err != nil {
+		checks = append(checks, DoctorCheck{"synq-token", CheckFail, fmt.Sprintf("token exchange failed: %s", err)})
+	} else {
+		checks = append(checks, DoctorCheck{"synq-token", CheckPass, "token exchanged successfully"})
+	}
+
+	if err := synq.CheckEndpoint(ctx, SynqApiEndpoint, 10*time.Second, synqUploadOpts()...); err != nil {
+		checks = append(checks, DoctorCheck{"synq-endpoint", CheckFail, err.Error()})
+	} else {
+		checks = append(checks, DoctorCheck{"synq-endpoint", CheckPass, fmt.Sprintf("%s is reachable", SynqApiEndpoint)})
+	}
+
+	return checks
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&DoctorJson, "json", DoctorJson, "Print results as JSON")
+
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import "testing"
+
+func TestDoctorResult(t *testing.T) {
+	strict := Strict
+	t.Cleanup(func() { Strict = strict })
+	Strict = false
+
+	passed := []DoctorCheck{
+		{"sqlmesh", CheckPass, "/usr/bin/sqlmesh"},
+		{"git", CheckWarn, "not a git repository"},
+		{"synq-token", CheckSkip, "token not set"},
+	}
+	if err := doctorResult(passed); err != nil {
+		t.Errorf("expected no error without failed checks, got %v", err)
+	}
+
+	failed := append(passed, DoctorCheck{"ui-port", CheckFail, "port 8080 is in use"})
+	if code := ExitCode(doctorResult(failed)); code != ExitCheckFailed {
+		t.Errorf("expected exit code %d, got %d", ExitCheckFailed, code)
+	}
+}
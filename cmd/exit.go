@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Exit codes of synq-sqlmesh. Failures to collect or deliver metadata fail
+// open: without `--strict` they exit with ExitOK so synq-sqlmesh never breaks
+// the pipeline it runs in. Configuration, usage, validation and output errors
+// and failed `doctor` checks always exit with their code.
+const (
+	ExitOK                 = 0
+	ExitConfigError        = 2
+	ExitSQLMeshUnavailable = 3
+	ExitPartialCollection  = 4
+	ExitUploadFailure      = 5
+	ExitValidationFailed   = 6
+	ExitOutputFailure      = 7
+	ExitCheckFailed        = 8
+)
+
+// failOpenExitCodes are exit codes only reported with `--strict`.
+var failOpenExitCodes = map[int]bool{
+	ExitSQLMeshUnavailable: true,
+	ExitPartialCollection:  true,
+	ExitUploadFailure:      true,
+}
+
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	var exitErr *exitCodeError
+	if errors.As(err, &exitErr) {
+		return err
+	}
+	return &exitCodeError{code: code, err: err}
+}
+
+// ExitCode maps the error to the process exit code. Errors without an
+// explicit code, e.g. invalid command line arguments, are configuration
+// errors.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	code := ExitConfigError
+	var exitErr *exitCodeError
+	if errors.As(err, &exitErr) {
+		code = exitErr.code
+	}
+	if failOpenExitCodes[code] && !Strict {
+		return ExitOK
+	}
+	return code
+}
+
+func exit(err error) {
+	code := ExitCode(err)
+	if err != nil && code == ExitOK {
+		logrus.Warn("Exiting with code 0, use --strict to fail on errors")
+	}
+	finishRunSummary(err)
+	finishTracing(err)
+	closeLogFile()
+	os.Exit(code)
+}
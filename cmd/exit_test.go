@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	failure := errors.New("failure")
+	tests := []struct {
+		name   string
+		err    error
+		code   int
+		strict int
+	}{
+		{"success", nil, ExitOK, ExitOK},
+		{"usage error", failure, ExitConfigError, ExitConfigError},
+		{"config error", withExitCode(ExitConfigError, failure), ExitConfigError, ExitConfigError},
+		{"sqlmesh unavailable", withExitCode(ExitSQLMeshUnavailable, failure), ExitOK, ExitSQLMeshUnavailable},
+		{"partial collection", withExitCode(ExitPartialCollection, failure), ExitOK, ExitPartialCollection},
+		{"upload failure", withExitCode(ExitUploadFailure, failure), ExitOK, ExitUploadFailure},
+		{"validation failed", withExitCode(ExitValidationFailed, failure), ExitValidationFailed, ExitValidationFailed},
+		{"output failure", withExitCode(ExitOutputFailure, failure), ExitOutputFailure, ExitOutputFailure},
+		{"check failed", withExitCode(ExitCheckFailed, failure), ExitCheckFailed, ExitCheckFailed},
+		{"wrapped", fmt.Errorf("upload: %w", withExitCode(ExitUploadFailure, failure)), ExitOK, ExitUploadFailure},
+		{"first code wins", withExitCode(ExitConfigError, withExitCode(ExitOutputFailure, failure)), ExitOutputFailure, ExitOutputFailure},
+	}
+
+	strict := Strict
+	t.Cleanup(func() { Strict = strict })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Strict = false
+			if code := ExitCode(tt.err); code != tt.code {
+				t.Errorf("expected %d, got %d", tt.code, code)
+			}
+			Strict = true
+			if code := ExitCode(tt.err); code != tt.strict {
+				t.Errorf("expected %d with --strict, got %d", tt.strict, code)
+			}
+		})
+	}
+}
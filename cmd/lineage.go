@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var LineageFrom = ""
+var LineageFormat = sqlmesh.LineageFormatDot
+var LineageModel = ""
+var LineageDepth = 0
+var LineageDirection = "both"
+var LineageOutput = ""
+
+var lineageCmd = &cobra.Command{
+	Use:   "lineage",
+	Short: "Work with the lineage graph of the SQLMesh project",
+}
+
+var lineageExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the lineage graph as Graphviz DOT, Mermaid or JSON adjacency list",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		var upstream, downstream bool
+		switch LineageDirection {
+		case "both":
+			upstream, downstream = true, true
+		case "upstream":
+			upstream = true
+		case "downstream":
+			downstream = true
+		default:
+			err := fmt.Errorf("invalid direction %s, expected upstream, downstream or both", LineageDirection)
+			fmt.Println(err)
+			exit(withExitCode(ExitConfigError, err))
+		}
+
+		switch LineageFormat {
+		case sqlmesh.LineageFormatDot, sqlmesh.LineageFormatMermaid, sqlmesh.LineageFormatJson:
+		default:
+			err := fmt.Errorf("invalid format %s, expected dot, mermaid or json", LineageFormat)
+			fmt.Println(err)
+			exit(withExitCode(ExitConfigError, err))
+		}
+
+		output, err := loadOrCollectMetadata(cmd.Context(), LineageFrom)
+		if err != nil {
+			fmt.Println(err)
+			exit(err)
+		}
+
+		metadata, errs := sqlmesh.DecodeMetadata(output)
+		for _, err := range errs {
+			logrus.Warn(err)
+		}
+
+		graph := metadata.Lineage
+		// models without lineage payload are still part of the project
+		for modelName := range metadata.Models {
+			if _, ok := graph[modelName]; !ok {
+				graph[modelName] = []string{}
+			}
+		}
+		if LineageModel != "" {
+			if _, ok := graph[LineageModel]; !ok {
+				err := fmt.Errorf("model %s not found", LineageModel)
+				fmt.Println(err)
+				exit(withExitCode(ExitConfigError, err))
+			}
+			graph = graph.Focus(LineageModel, LineageDepth, upstream, downstream)
+		}
+
+		var w io.Writer = os.Stdout
+		if LineageOutput != "" {
+			f, err := os.Create(LineageOutput)
+			if err != nil {
+				fmt.Println(err)
+				exit(withExitCode(ExitOutputFailure, err))
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if err := sqlmesh.RenderLineage(w, graph, LineageFormat, LineageModel); err != nil {
+			fmt.Println(err)
+			exit(withExitCode(ExitConfigError, err))
+		}
+	},
+}
+
+func init() {
+	lineageExportCmd.Flags().StringVar(&LineageFrom, "from", LineageFrom, "Export lineage of a metadata dump created by collect instead of collecting")
+	lineageExportCmd.Flags().StringVar(&LineageFormat, "format", LineageFormat, "Output format: dot, mermaid or json")
+	lineageExportCmd.Flags().StringVar(&LineageModel, "model", LineageModel, "Only export lineage of this model")
+	lineageExportCmd.Flags().IntVar(&LineageDepth, "depth", LineageDepth, "Number of steps from --model to include, 0 for unlimited")
+	lineageExportCmd.Flags().StringVar(&LineageDirection, "direction", LineageDirection, "Direction from --model to include: upstream, downstream or both")
+	lineageExportCmd.Flags().StringVarP(&LineageOutput, "output", "o", LineageOutput, "Write to file instead of stdout")
+
+	lineageCmd.AddCommand(lineageExportCmd)
+	rootCmd.AddCommand(lineageCmd)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	LogFormatText   = "text"
+	LogFormatJson   = "json"
+	LogFormatLogfmt = "logfmt"
+)
+
+var LogLevel = logrus.InfoLevel.String()
+var LogFormat = LogFormatText
+var LogFile = ""
+
+// logFile is the open --log-file, see closeLogFile.
+var logFile *os.File
+
+// textFormatter renders `<time>  <message> key=value ...`, the compact format
+// used since the first release, followed by the fields of the entry.
+type textFormatter struct{}
+
+func (f *textFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(entry.Time.Format("15:04:05"))
+	b.WriteString("  ")
+	b.WriteString(entry.Message)
+
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := fmt.Sprint(entry.Data[key])
+		if strings.ContainsAny(value, " \"=\n") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, value)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func logFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case LogFormatText:
+		return &textFormatter{}, nil
+	case LogFormatJson:
+		return &logrus.JSONFormatter{}, nil
+	case LogFormatLogfmt:
+		return &logrus.TextFormatter{DisableColors: true, FullTimestamp: true}, nil
+	default:
+		return nil, fmt.Errorf("invalid log format %s, expected text, json or logfmt", format)
+	}
+}
+
+// configureLogging applies --log-level, --log-format and --log-file. Logs are
+// written to stderr and appended to the log file when set.
+func configureLogging() error {
+	level, err := logrus.ParseLevel(LogLevel)
+	if err != nil {
+		return withExitCode(ExitConfigError, fmt.Errorf("invalid log level %s: %w", LogLevel, err))
+	}
+	formatter, err := logFormatter(LogFormat)
+	if err != nil {
+		return withExitCode(ExitConfigError, err)
+	}
+
+	logrus.SetLevel(level)
+	logrus.SetFormatter(formatter)
+	closeLogFile()
+	if LogFile != "" {
+		f, err := os.OpenFile(LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return withExitCode(ExitConfigError, fmt.Errorf("failed to open log file: %w", err))
+		}
+		logFile = f
+		logrus.SetOutput(io.MultiWriter(os.Stderr, f))
+	}
+	return nil
+}
+
+// closeLogFile syncs and closes --log-file when the command exits, later
+// logs go to stderr only.
+func closeLogFile() {
+	if logFile == nil {
+		return
+	}
+	logrus.SetOutput(os.Stderr)
+	if err := logFile.Sync(); err != nil {
+		logrus.WithError(err).Warn("Failed to sync log file")
+	}
+	if err := logFile.Close(); err != nil {
+		logrus.WithError(err).Warn("Failed to close log file")
+	}
+	logFile = nil
+}
+
+func init() {
+	// used until flags are parsed
+	logrus.SetFormatter(&textFormatter{})
+
+	rootCmd.PersistentFlags().StringVar(&LogLevel, "log-level", LogLevel, "Log level: trace, debug, info, warn or error")
+	rootCmd.PersistentFlags().StringVar(&LogFormat, "log-format", LogFormat, "Log format: text, json or logfmt")
+	rootCmd.PersistentFlags().StringVar(&LogFile, "log-file", LogFile, "File logs are appended to in addition to stderr")
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestCloseLogFile(t *testing.T) {
+	logLevel, logFormat, logFilePath := LogLevel, LogFormat, LogFile
+	t.Cleanup(func() {
+		closeLogFile()
+		LogLevel, LogFormat, LogFile = logLevel, logFormat, logFilePath
+		logrus.SetOutput(os.Stderr)
+		logrus.SetLevel(logrus.InfoLevel)
+		logrus.SetFormatter(&textFormatter{})
+	})
+	path := filepath.Join(t.TempDir(), "synq-sqlmesh.log")
+	LogLevel, LogFormat, LogFile = "info", LogFormatText, path
+
+	if err := configureLogging(); err != nil {
+		t.Fatal(err)
+	}
+	logrus.Info("before close")
+	closeLogFile()
+	if logFile != nil {
+		t.Error("expected log file to be closed")
+	}
+	logrus.Info("after close")
+	// closing twice, e.g. by exit and Execute, is fine
+	closeLogFile()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "before close") || strings.Contains(string(content), "after close") {
+		t.Errorf("expected only logs before close, got %q", content)
+	}
+}
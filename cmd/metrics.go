@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/getsynq/synq-sqlmesh/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+var MetricsFile = ""
+var MetricsPushgateway = ""
+var MetricsJob = "synq_sqlmesh"
+
+const metricsPrefix = "synq_sqlmesh_"
+
+// runMetrics converts the summary to Prometheus metrics labeled with project
+// and command. The last success timestamp is only present when the command
+// succeeded, so the previous value is kept after failed runs.
+func runMetrics(summary *RunSummary, group metrics.Labels) []*metrics.Family {
+	gauge := func(name string, help string, value float64) *metrics.Family {
+		return metrics.Gauge(metricsPrefix+name, help, group, value)
+	}
+
+	finishedAt := summary.StartedAt.Add(time.Duration(summary.DurationMs) * time.Millisecond)
+	families := []*metrics.Family{
+		gauge("last_run_timestamp_seconds", "Unix time the last run finished.", float64(finishedAt.Unix())),
+		gauge("run_duration_seconds", "Duration of the last run.", float64(summary.DurationMs)/1000),
+		gauge("exit_code", "Exit code of the last run.", float64(summary.ExitCode)),
+		gauge("output_bytes", "Size of the collect output of the last run.", float64(summary.OutputBytes)),
+		gauge("upload_bytes", "Size of the upload request of the last run.", float64(summary.UploadBytes)),
+	}
+	if summary.LogFiles > 0 {
+		families = append(families, gauge("log_files_collected", "Log files collected by the last run.", float64(summary.LogFiles)))
+	} else if summary.collected {
+		families = append(families,
+			gauge("models_collected", "Models collected by the last run.", float64(summary.Models)),
+			gauge("lineage_entries_collected", "Lineage entries collected by the last run.", float64(summary.LineageEntries)),
+			gauge("files_collected", "Files collected by the last run.", float64(summary.Files)),
+			gauge("file_content_bytes", "Size of the file content collected by the last run.", float64(summary.FileContentBytes)),
+		)
+	}
+
+	apiErrors := &metrics.Family{Name: metricsPrefix + "api_errors", Help: "Collection errors of the last run by SQLMesh UI endpoint and status code.", Type: metrics.TypeGauge}
+	for _, summaryErr := range summary.Errors {
+		apiErrors.Samples = append(apiErrors.Samples, metrics.Sample{
+			Labels: group.With("endpoint", summaryErr.Endpoint).With("status", strconv.FormatInt(summaryErr.Status, 10)),
+			Value:  float64(summaryErr.Count),
+		})
+	}
+	families = append(families, apiErrors)
+
+	phases := &metrics.Family{Name: metricsPrefix + "phase_duration_seconds", Help: "Duration of the phases of the last run.", Type: metrics.TypeGauge}
+	for _, phase := range summary.Phases {
+		phases.Samples = append(phases.Samples, metrics.Sample{
+			Labels: group.With("phase", phase.Phase),
+			Value:  float64(phase.DurationMs) / 1000,
+		})
+	}
+	families = append(families, phases)
+
+	if summary.Error == "" {
+		families = append(families, gauge("last_success_timestamp_seconds", "Unix time the last successful run finished.", float64(finishedAt.Unix())))
+	}
+	return families
+}
+
+// exportMetrics writes --metrics-file and pushes to --metrics-pushgateway,
+// failures are logged but never change the exit code.
+func exportMetrics(summary *RunSummary) {
+	if MetricsFile == "" && MetricsPushgateway == "" {
+		return
+	}
+
+	project := summary.Project
+	if project == "" {
+		if abs, err := filepath.Abs(SQLMeshProjectDir); err == nil {
+			project = filepath.Base(abs)
+		}
+	}
+	group := metrics.Labels{"project": project, "command": summary.Command}
+	families := runMetrics(summary, group)
+
+	if MetricsFile != "" {
+		if err := metrics.WriteTextfile(MetricsFile, group, families); err != nil {
+			logrus.WithError(err).WithField("file", MetricsFile).Error("Failed to write metrics file")
+		}
+	}
+	if MetricsPushgateway != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		// a successful run replaces the whole group, dropping errors of
+		// endpoints which recovered
+		if err := metrics.Push(ctx, MetricsPushgateway, MetricsJob, group, families, summary.Error == ""); err != nil {
+			logrus.WithError(err).Error("Failed to push metrics")
+		}
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&MetricsFile, "metrics-file", MetricsFile, "Prometheus textfile collector file metrics of the run are written to, e.g. /var/lib/node_exporter/synq_sqlmesh.prom")
+	rootCmd.PersistentFlags().StringVar(&MetricsPushgateway, "metrics-pushgateway", MetricsPushgateway, "Prometheus Pushgateway URL metrics of collect and upload commands are pushed to")
+	rootCmd.PersistentFlags().StringVar(&MetricsJob, "metrics-job", MetricsJob, "Job label of metrics pushed to the Pushgateway")
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sqlmeshv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/sqlmesh/v1"
+	"github.com/getsynq/synq-sqlmesh/openlineage"
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/sirupsen/logrus"
+)
+
+var OpenLineageUrl = ""
+var OpenLineageFile = ""
+var OpenLineageApiKey = ""
+var OpenLineageNamespace = openlineage.DefaultNamespace
+
+// openLineageTransport returns nil when OpenLineage export is not configured.
+func openLineageTransport() openlineage.Transport {
+	var transports openlineage.MultiTransport
+	if OpenLineageFile != "" {
+		transports = append(transports, openlineage.NewFileTransport(OpenLineageFile))
+	}
+	if OpenLineageUrl != "" {
+		transports = append(transports, openlineage.NewHttpTransport(OpenLineageUrl, OpenLineageApiKey))
+	}
+	if len(transports) == 0 {
+		return nil
+	}
+	return transports
+}
+
+func emitOpenLineageMetadata(ctx context.Context, output *sqlmeshv1.IngestMetadataRequest) error {
+	transport := openLineageTransport()
+	if transport == nil {
+		return nil
+	}
+
+	metadata, errs := sqlmesh.DecodeMetadata(output)
+	for _, err := range errs {
+		logrus.Warn(err)
+	}
+	start := time.Now()
+	events := openlineage.MetadataEvents(metadata, OpenLineageNamespace, output.StateAt.AsTime())
+	if err := transport.Emit(ctx, events); err != nil {
+		return withExitCode(ExitUploadFailure, fmt.Errorf("failed to emit OpenLineage events: %w", err))
+	}
+	recordPhase("openlineage", start)
+	logrus.Infof("Emitted %d OpenLineage events", len(events))
+	return nil
+}
+
+func emitOpenLineageExecution(ctx context.Context, output *sqlmeshv1.IngestExecutionRequest) error {
+	transport := openLineageTransport()
+	if transport == nil {
+		return nil
+	}
+
+	start := time.Now()
+	events := openlineage.ExecutionEvents(output, OpenLineageNamespace)
+	if err := transport.Emit(ctx, events); err != nil {
+		return withExitCode(ExitUploadFailure, fmt.Errorf("failed to emit OpenLineage events: %w", err))
+	}
+	recordPhase("openlineage", start)
+	logrus.Infof("Emitted %d OpenLineage events", len(events))
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&OpenLineageUrl, "openlineage-url", OpenLineageUrl, "OpenLineage HTTP endpoint events are POSTed to, e.g. http://localhost:5000/api/v1/lineage")
+	rootCmd.PersistentFlags().StringVar(&OpenLineageFile, "openlineage-file", OpenLineageFile, "File OpenLineage events are appended to as newline delimited JSON")
+	rootCmd.PersistentFlags().StringVar(&OpenLineageApiKey, "openlineage-api-key", OpenLineageApiKey, "Bearer token sent to --openlineage-url")
+	rootCmd.PersistentFlags().StringVar(&OpenLineageNamespace, "openlineage-namespace", OpenLineageNamespace, "OpenLineage namespace of emitted jobs and datasets")
+
+	secretFlags["openlineage-api-key"] = true
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmeshv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/sqlmesh/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// setOpenLineage sets the SYNQ token and OpenLineage file for the test.
+func setOpenLineage(t *testing.T, token string, file string) {
+	t.Helper()
+	synqApiToken, synqApiEndpoint, openLineageFile := SynqApiToken, SynqApiEndpoint, OpenLineageFile
+	t.Cleanup(func() {
+		SynqApiToken, SynqApiEndpoint, OpenLineageFile = synqApiToken, synqApiEndpoint, openLineageFile
+	})
+	SynqApiToken, OpenLineageFile = token, file
+	// nothing listens on port 1, uploads fail right away
+	SynqApiEndpoint = "127.0.0.1:1"
+}
+
+func testMetadata() *sqlmeshv1.IngestMetadataRequest {
+	return &sqlmeshv1.IngestMetadataRequest{
+		Models:  []byte(`[{"name": "example.orders", "type": "sql"}]`),
+		StateAt: timestamppb.New(time.Date(2026, 10, 1, 6, 0, 0, 0, time.UTC)),
+	}
+}
+
+func TestUploadMetadataOpenLineage(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{"without token", "", ExitOK},
+		{"upload failure", "token", ExitUploadFailure},
+	}
+
+	strict := Strict
+	t.Cleanup(func() { Strict = strict })
+	Strict = true
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "openlineage.ndjson")
+			setOpenLineage(t, tt.token, file)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if code := ExitCode(uploadMetadata(ctx, testMetadata())); code != tt.code {
+				t.Errorf("expected exit code %d, got %d", tt.code, code)
+			}
+			// events are emitted whether the upload succeeds or not
+			events, _ := os.ReadFile(file)
+			if !strings.Contains(string(events), `"name":"example.orders"`) {
+				t.Errorf("expected events of example.orders, got %q", events)
+			}
+		})
+	}
+}
+
+func TestUploadMetadataRequiresTokenOrOpenLineage(t *testing.T) {
+	setOpenLineage(t, "", "")
+	if code := ExitCode(uploadMetadata(context.Background(), testMetadata())); code != ExitConfigError {
+		t.Errorf("expected exit code %d, got %d", ExitConfigError, code)
+	}
+}
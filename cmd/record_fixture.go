@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/getsynq/synq-sqlmesh/sqlmesh/sqlmeshtest"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var recordFixtureCmd = &cobra.Command{
+	Use:   "record-fixture",
+	Short: "Record responses of SQLMesh UI into a fixture for offline testing",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := WithSQLMesh(func(baseUrl url.URL) error {
+			logrus.Info("SQLMesh base URL:", baseUrl.String())
+
+			fixture, err := sqlmeshtest.Record(baseUrl, sqlmesh.NewGlobFilter(SQLMeshCollectFileContentIncludePattern, SQLMeshCollectFileContentExcludePattern))
+			if err != nil {
+				return err
+			}
+			logrus.Infof("Recorded %d responses of SQLMesh %s", len(fixture.Responses), fixture.SQLMeshVersion)
+
+			return fixture.Save(args[0])
+		})
+		if err != nil {
+			fmt.Println(err)
+			exit(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recordFixtureCmd)
+}
@@ -6,7 +6,9 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
+	ingestgitv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/git/v1"
 	sqlmeshv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/sqlmesh/v1"
 	"github.com/getsynq/synq-sqlmesh/build"
 	"github.com/getsynq/synq-sqlmesh/git"
@@ -15,6 +17,7 @@ import (
 	"github.com/getsynq/synq-sqlmesh/synq"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
 )
 
 var rootCmd = &cobra.Command{
@@ -33,29 +36,54 @@ var versionCmd = &cobra.Command{
 var collectCmd = &cobra.Command{
 	Use:   "collect",
 	Short: "Collect metadata information from SQLMesh and store to the file",
+	Long:  "Collect metadata information from SQLMesh and store to the file. Output is gzip compressed when the file name ends with `.gz`, e.g. `meta.json.gz`.",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 
-		gitContext := git.CollectGitContext(cmd.Context(), SQLMeshProjectDir)
+		gitDetails := collectGitDetails(cmd.Context())
+		gitContext := gitDetails.GitContext()
+		projectConfig, err := readProjectConfig()
+		if err != nil {
+			fmt.Println(err)
+			exit(err)
+		}
+		recordProject(projectConfig)
 
-		err := WithSQLMesh(func(baseUrl url.URL) error {
+		err = WithSQLMesh(func(baseUrl url.URL) error {
 			logrus.Info("SQLMesh base URL:", baseUrl.String())
 
-			output, err := sqlmesh.CollectMetadata(baseUrl, createFileContentGlobFilter())
+			output, sqlMeshVersion, impactReport, err := collectMetadata(cmd.Context(), baseUrl, gitContext)
 			if err != nil {
 				return err
 			}
-			output.GitContext = gitContext
+			recordImpactReport(impactReport)
 
-			if err := synq.DumpMetadata(output, args[0]); err != nil {
+			dumpOpts := []synq.DumpOpt{
+				synq.WithProjectConfig(projectConfig),
+				synq.WithSQLMeshVersion(sqlMeshVersion),
+				synq.WithGitDetails(gitDetails),
+				synq.WithImpactReport(impactReport),
+			}
+			if GitFileHistory {
+				fileHistory := recordFileHistory(cmd.Context(), output)
+				dumpOpts = append(dumpOpts, synq.WithFileHistory(fileHistory))
+			}
+			dumpStart := time.Now()
+			if err := synq.DumpMetadata(output, args[0], dumpOpts...); err != nil {
+				return withExitCode(ExitOutputFailure, err)
+			}
+			recordPhase("dump", dumpStart)
+			recordOutputFile(args[0])
+
+			if err := emitOpenLineageMetadata(cmd.Context(), output); err != nil {
 				return err
 			}
 
-			return nil
+			return checkCollectionErrors(output)
 		})
 		if err != nil {
 			fmt.Println(err)
-			os.Exit(0)
+			exit(err)
 		}
 	},
 }
@@ -65,33 +93,45 @@ var uploadCmd = &cobra.Command{
 	Short: "Collect metadata information from SQLMesh and send to SYNQ API",
 	Args:  cobra.ExactArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := runUpload(cmd.Context()); err != nil {
+			fmt.Println(err)
+			exit(err)
+		}
+	},
+}
 
-		gitContext := git.CollectGitContext(cmd.Context(), SQLMeshProjectDir)
-
-		err := WithSQLMesh(func(baseUrl url.URL) error {
-			logrus.Info("SQLMesh base URL:", baseUrl.String())
-
-			output, err := sqlmesh.CollectMetadata(baseUrl, createFileContentGlobFilter())
-			if err != nil {
-				return err
-			}
-			output.GitContext = gitContext
-
-			if SynqApiToken == "" {
-				return fmt.Errorf("SYNQ_TOKEN environment variable is not set")
-			}
+// runUpload collects metadata from SQLMesh and uploads it to SYNQ.
+func runUpload(ctx context.Context) error {
+	gitDetails := collectGitDetails(ctx)
+	gitContext := gitDetails.GitContext()
+	projectConfig, err := readProjectConfig()
+	if err != nil {
+		return err
+	}
+	recordProject(projectConfig)
 
-			if err := synq.UploadMetadata(cmd.Context(), output, SynqApiEndpoint, SynqApiToken); err != nil {
-				return err
-			}
+	return WithSQLMesh(func(baseUrl url.URL) error {
+		logrus.Info("SQLMesh base URL:", baseUrl.String())
 
-			return nil
-		})
+		output, _, impactReport, err := collectMetadata(ctx, baseUrl, gitContext)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(0)
+			return err
 		}
-	},
+		recordImpactReport(impactReport)
+		if GitFileHistory {
+			logrus.Warn("--git-file-history is only stored in collect output, the ingest API has no field for it")
+		}
+
+		if projectConfig != nil && projectConfig.Project != "" {
+			logrus.Infof("Uploading metadata of SQLMesh project %s", projectConfig.Project)
+		}
+		logGitDetails(gitDetails)
+		if err := uploadMetadata(ctx, output); err != nil {
+			return err
+		}
+
+		return checkCollectionErrors(output)
+	})
 }
 
 var uploadAuditCmd = &cobra.Command{
@@ -100,7 +140,8 @@ var uploadAuditCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 
-		gitContext := git.CollectGitContext(cmd.Context(), SQLMeshProjectDir)
+		gitDetails := collectGitDetails(cmd.Context())
+		gitContext := gitDetails.GitContext()
 		output := &sqlmeshv1.IngestExecutionRequest{
 			Command:    []string{"sqlmesh", "audit"},
 			GitContext: gitContext,
@@ -108,22 +149,18 @@ var uploadAuditCmd = &cobra.Command{
 		output.UploaderVersion = strings.TrimSpace(fmt.Sprintf("synq-sqlmesh/%s", build.Version))
 		output.UploaderBuildTime = strings.TrimSpace(build.Time)
 
+		recordLogFiles(len(args))
 		for _, fileArg := range args {
 			err := sqlmesh.CollectExecutionLog(output, fileArg)
 			if err != nil {
 				logrus.WithError(err).WithField("path", fileArg).Error("Failed to collect audit log")
-				os.Exit(0)
+				exit(withExitCode(ExitConfigError, err))
 			}
 		}
 
-		if SynqApiToken == "" {
-			logrus.Error("SYNQ_TOKEN environment variable is not set")
-			os.Exit(0)
-		}
-
-		if err := synq.UploadExecutionLog(cmd.Context(), output, SynqApiEndpoint, SynqApiToken); err != nil {
-			logrus.WithError(err).Error("Failed to upload execution log")
-			os.Exit(0)
+		logGitDetails(gitDetails)
+		if err := uploadExecutionLog(cmd.Context(), output); err != nil {
+			exit(err)
 		}
 	},
 }
@@ -134,7 +171,8 @@ var uploadRunCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 
-		gitContext := git.CollectGitContext(cmd.Context(), SQLMeshProjectDir)
+		gitDetails := collectGitDetails(cmd.Context())
+		gitContext := gitDetails.GitContext()
 		output := &sqlmeshv1.IngestExecutionRequest{
 			Command:    []string{"sqlmesh", "run"},
 			GitContext: gitContext,
@@ -142,26 +180,77 @@ var uploadRunCmd = &cobra.Command{
 		output.UploaderVersion = strings.TrimSpace(fmt.Sprintf("synq-sqlmesh/%s", build.Version))
 		output.UploaderBuildTime = strings.TrimSpace(build.Time)
 
+		recordLogFiles(len(args))
 		for _, fileArg := range args {
 			err := sqlmesh.CollectExecutionLog(output, fileArg)
 			if err != nil {
 				logrus.WithError(err).WithField("path", fileArg).Error("Failed to collect run log")
-				os.Exit(0)
+				exit(withExitCode(ExitConfigError, err))
 			}
 		}
 
-		if SynqApiToken == "" {
-			logrus.Error("SYNQ_TOKEN environment variable is not set")
-			os.Exit(0)
-		}
-
-		if err := synq.UploadExecutionLog(cmd.Context(), output, SynqApiEndpoint, SynqApiToken); err != nil {
-			logrus.WithError(err).Error("Failed to upload execution log")
-			os.Exit(0)
+		logGitDetails(gitDetails)
+		if err := uploadExecutionLog(cmd.Context(), output); err != nil {
+			exit(err)
 		}
 	},
 }
 
+// uploadMetadata emits OpenLineage events and uploads the metadata to SYNQ,
+// like uploadExecutionLog does with execution logs.
+func uploadMetadata(ctx context.Context, output *sqlmeshv1.IngestMetadataRequest) error {
+	if SynqApiToken == "" && openLineageTransport() == nil {
+		return withExitCode(ExitConfigError, fmt.Errorf("SYNQ_TOKEN environment variable is not set"))
+	}
+
+	openLineageErr := emitOpenLineageMetadata(ctx, output)
+	if openLineageErr != nil {
+		logrus.Error(openLineageErr)
+	}
+
+	if SynqApiToken == "" {
+		logrus.Warn("SYNQ_TOKEN environment variable is not set, skipping upload to SYNQ")
+		return openLineageErr
+	}
+	recordUploadSize(proto.Size(output))
+	uploadStart := time.Now()
+	if err := synq.UploadMetadata(ctx, output, SynqApiEndpoint, SynqApiToken, synqUploadOpts()...); err != nil {
+		return withExitCode(ExitUploadFailure, err)
+	}
+	recordPhase("upload", uploadStart)
+	return openLineageErr
+}
+
+// uploadExecutionLog emits OpenLineage events and uploads the execution log
+// to SYNQ. The SYNQ token is only required when OpenLineage export is not
+// configured, without it the upload to SYNQ is skipped. OpenLineage failures
+// don't prevent the upload to SYNQ, the first error is returned.
+func uploadExecutionLog(ctx context.Context, output *sqlmeshv1.IngestExecutionRequest) error {
+	if SynqApiToken == "" && openLineageTransport() == nil {
+		err := fmt.Errorf("SYNQ_TOKEN environment variable is not set")
+		logrus.Error(err)
+		return withExitCode(ExitConfigError, err)
+	}
+
+	openLineageErr := emitOpenLineageExecution(ctx, output)
+	if openLineageErr != nil {
+		logrus.Error(openLineageErr)
+	}
+
+	if SynqApiToken == "" {
+		logrus.Warn("SYNQ_TOKEN environment variable is not set, skipping upload to SYNQ")
+		return openLineageErr
+	}
+	recordUploadSize(proto.Size(output))
+	uploadStart := time.Now()
+	if err := synq.UploadExecutionLog(ctx, output, SynqApiEndpoint, SynqApiToken, synqUploadOpts()...); err != nil {
+		logrus.WithError(err).Error("Failed to upload execution log")
+		return withExitCode(ExitUploadFailure, err)
+	}
+	recordPhase("upload", uploadStart)
+	return openLineageErr
+}
+
 func createFileContentGlobFilter() sqlmesh.GlobFilter {
 	if SQLMeshCollectFileContent {
 		return sqlmesh.NewGlobFilter(SQLMeshCollectFileContentIncludePattern, SQLMeshCollectFileContentExcludePattern)
@@ -169,6 +258,234 @@ func createFileContentGlobFilter() sqlmesh.GlobFilter {
 	return sqlmesh.NewExcludeEverythingGlobFilter()
 }
 
+// collectMetadata collects metadata from the running SQLMesh UI with the
+// configured options. With --changed-since only changed models are collected
+// and their impact is reported.
+func collectMetadata(ctx context.Context, baseUrl url.URL, gitContext *ingestgitv1.GitContext) (*sqlmeshv1.IngestMetadataRequest, sqlmesh.Version, *sqlmesh.ImpactReport, error) {
+	versionStart := time.Now()
+	// meta is fetched once, for the version and for the collection
+	meta, metaErr := sqlmesh.NewAPIClient(baseUrl).GetMeta()
+	if metaErr != nil {
+		logrus.WithError(metaErr).Debug("SQLMesh meta information not available")
+		meta = nil
+	}
+	sqlMeshVersion, err := detectSQLMeshVersion(ctx, meta)
+	if err != nil {
+		return nil, sqlMeshVersion, nil, err
+	}
+	recordPhase("sqlmesh_version", versionStart)
+
+	changed, err := changedFiles(ctx)
+	if err != nil {
+		return nil, sqlMeshVersion, nil, err
+	}
+	opts := append(collectOpts(), sqlmesh.WithContext(ctx))
+	if meta != nil {
+		opts = append(opts, sqlmesh.WithApiMeta(meta))
+	}
+	if changed != nil {
+		opts = append(opts, sqlmesh.WithChangedFiles(changed, ChangedDownstream))
+	}
+
+	output, err := sqlmesh.CollectMetadata(baseUrl, createFileContentGlobFilter(), opts...)
+	if err != nil {
+		return nil, sqlMeshVersion, nil, err
+	}
+	output.GitContext = gitContext
+	recordMetadata(output)
+
+	var impactReport *sqlmesh.ImpactReport
+	if changed != nil {
+		impactReport = sqlmesh.BuildImpactReport(output, ChangedSince, changed)
+		if err := reportImpact(impactReport); err != nil {
+			return nil, sqlMeshVersion, nil, err
+		}
+	}
+
+	return output, sqlMeshVersion, impactReport, nil
+}
+
+// loadOrCollectMetadata loads the dump when given, otherwise metadata is
+// collected from SQLMesh like `collect` does.
+func loadOrCollectMetadata(ctx context.Context, from string) (*sqlmeshv1.IngestMetadataRequest, error) {
+	if from != "" {
+		output, err := synq.LoadMetadata(from)
+		if err != nil {
+			return nil, withExitCode(ExitConfigError, err)
+		}
+		recordMetadata(output)
+		return output, nil
+	}
+
+	gitContext := git.CollectGitContext(ctx, SQLMeshProjectDir, git.WithRemote(GitRemote))
+	if _, err := readProjectConfig(); err != nil {
+		return nil, err
+	}
+
+	var output *sqlmeshv1.IngestMetadataRequest
+	err := WithSQLMesh(func(baseUrl url.URL) error {
+		logrus.Info("SQLMesh base URL:", baseUrl.String())
+		var err error
+		output, _, _, err = collectMetadata(ctx, baseUrl, gitContext)
+		return err
+	})
+	return output, err
+}
+
+// recordFileHistory collects the file history stored in collect output.
+func recordFileHistory(ctx context.Context, output *sqlmeshv1.IngestMetadataRequest) map[string]*git.FileCommit {
+	start := time.Now()
+	history := collectFileHistory(ctx, output)
+	recordPhase("git_file_history", start)
+	return history
+}
+
+// collectFileHistory finds the last commit of every file defining a
+// collected model.
+func collectFileHistory(ctx context.Context, output *sqlmeshv1.IngestMetadataRequest) map[string]*git.FileCommit {
+	metadata, _ := sqlmesh.DecodeMetadata(output)
+	if metadata.Files == nil {
+		return nil
+	}
+	modelPaths := map[string]bool{}
+	for _, model := range metadata.Models {
+		modelPaths[model.Path] = true
+	}
+	var paths []string
+	for _, path := range metadata.Files.FilePaths() {
+		if modelPaths[path] {
+			paths = append(paths, path)
+		}
+	}
+
+	history, err := git.FileHistory(ctx, SQLMeshProjectDir, paths)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to collect git history of model files")
+		return nil
+	}
+	logrus.Infof("Collected git history of %d model files", len(history))
+	return history
+}
+
+// collectGitDetails collects details of the checked out code of the project.
+func collectGitDetails(ctx context.Context) *git.Details {
+	start := time.Now()
+	details := git.CollectGitDetails(ctx, SQLMeshProjectDir, git.WithRemote(GitRemote))
+	recordPhase("git", start)
+	return details
+}
+
+// logGitDetails logs details of the checked out code beyond GitContext, so
+// the run can be traced back to the exact code from logs of uploads, the
+// ingest API can't carry them.
+func logGitDetails(details *git.Details) {
+	if details == nil || details.CommitSha == "" {
+		return
+	}
+	message := fmt.Sprintf("Git commit %s", details.CommitSha)
+	if details.Subject != "" {
+		message += fmt.Sprintf(" %q", details.Subject)
+	}
+	if details.Author != nil {
+		message += fmt.Sprintf(" by %s <%s>", details.Author.Name, details.Author.Email)
+	}
+	if details.CommitTime != nil {
+		message += fmt.Sprintf(" at %s", details.CommitTime.Format(time.RFC3339))
+	}
+	if len(details.Tags) > 0 {
+		message += fmt.Sprintf(", tags %s", strings.Join(details.Tags, ", "))
+	}
+	if details.Upstream != "" {
+		message += fmt.Sprintf(", tracking %s", details.Upstream)
+	}
+	logrus.Info(message)
+
+	if ci := details.CI; ci != nil {
+		message := fmt.Sprintf("CI %s build of %s", ci.Provider, details.Branch)
+		if ci.PullRequest != "" {
+			message += fmt.Sprintf(", pull request %s", ci.PullRequest)
+		}
+		if ci.Actor != "" {
+			message += fmt.Sprintf(", triggered by %s", ci.Actor)
+		}
+		if ci.BuildUrl != "" {
+			message += fmt.Sprintf(": %s", ci.BuildUrl)
+		}
+		logrus.Info(message)
+	}
+
+	if details.Dirty {
+		if len(details.DirtyFiles) > 0 {
+			logrus.Warnf("Work tree has uncommitted changes of SQLMesh files: %s", strings.Join(details.DirtyFiles, ", "))
+		} else {
+			logrus.Warn("Work tree has uncommitted changes")
+		}
+	}
+}
+
+// checkCollectionErrors turns API errors recorded during the collection into
+// a failure, but only in strict mode as partial metadata is still uploaded.
+func checkCollectionErrors(output *sqlmeshv1.IngestMetadataRequest) error {
+	if !Strict || len(output.Errors) == 0 {
+		return nil
+	}
+	return withExitCode(ExitPartialCollection, fmt.Errorf("%d errors recorded while collecting metadata", len(output.Errors)))
+}
+
+// readProjectConfig discovers SQLMesh project configuration, defaults
+// --sqlmesh-gateway to the default gateway of the project and validates the
+// selected gateway exists.
+func readProjectConfig() (*sqlmesh.ProjectConfig, error) {
+	projectConfig, err := sqlmesh.ReadProjectConfig(SQLMeshProjectDir)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to read SQLMesh project config")
+		return nil, nil
+	}
+	if projectConfig == nil {
+		logrus.Warnf("No SQLMesh config found in %s", SQLMeshProjectDir)
+		return nil, nil
+	}
+
+	logrus.Infof("SQLMesh project config %s: project=%q default gateway=%q gateways=%v dialect=%q",
+		projectConfig.Path, projectConfig.Project, projectConfig.DefaultGateway, projectConfig.GatewayNames(), projectConfig.Dialect())
+
+	if SQLMeshGateway != "" && len(projectConfig.Gateways) > 0 && !projectConfig.HasGateway(SQLMeshGateway) {
+		return projectConfig, withExitCode(ExitConfigError, fmt.Errorf("gateway %s not found in %s, available gateways: %s",
+			SQLMeshGateway, projectConfig.Path, strings.Join(projectConfig.GatewayNames(), ", ")))
+	}
+	if SQLMeshGateway == "" && projectConfig.DefaultGateway != "" {
+		SQLMeshGateway = projectConfig.DefaultGateway
+		logrus.Infof("Using default gateway %s of the SQLMesh project", SQLMeshGateway)
+	}
+
+	return projectConfig, nil
+}
+
+func collectOpts() []sqlmesh.CollectOpt {
+	opts := []sqlmesh.CollectOpt{
+		sqlmesh.WithConcurrency(SQLMeshConcurrency),
+		sqlmesh.WithPhaseObserver(func(phase string, duration time.Duration) {
+			recordPhaseDuration("collect_"+phase, duration)
+		}),
+	}
+	if SQLMeshSelect != "" || SQLMeshExclude != "" {
+		selectPattern := SQLMeshSelect
+		if selectPattern == "" {
+			selectPattern = "**"
+		}
+		opts = append(opts, sqlmesh.WithModelSelector(sqlmesh.NewGlobFilter(selectPattern, SQLMeshExclude)))
+	}
+	return opts
+}
+
+func synqUploadOpts() []synq.UploadOpt {
+	return []synq.UploadOpt{
+		synq.WithCompression(SynqApiCompression),
+		synq.WithCAFile(SynqCAFile),
+		synq.WithClientCertificate(SynqClientCertFile, SynqClientKeyFile),
+	}
+}
+
 func WithSQLMesh(f func(baseUrl url.URL) error) error {
 	baseUrl := url.URL{
 		Host:   fmt.Sprintf("%s:%d", SQLMeshUiHost, SQLMeshUiPort),
@@ -177,12 +494,22 @@ func WithSQLMesh(f func(baseUrl url.URL) error) error {
 	if SQLMeshUiStart {
 		ctx, cancelFn := context.WithCancel(context.Background())
 		defer cancelFn()
-		sqlMeshProcess, err := process.ExecuteCommand(ctx, SQLMesh, []string{"ui", "--host", SQLMeshUiHost, "--port", fmt.Sprintf("%d", SQLMeshUiPort)}, process.WithDir(SQLMeshProjectDir))
+		var sqlMeshArgs []string
+		if SQLMeshGateway != "" {
+			sqlMeshArgs = append(sqlMeshArgs, "--gateway", SQLMeshGateway)
+		}
+		sqlMeshArgs = append(sqlMeshArgs, "ui", "--host", SQLMeshUiHost, "--port", fmt.Sprintf("%d", SQLMeshUiPort))
+		startedAt := time.Now()
+		sqlMeshProcess, err := process.ExecuteCommand(ctx, SQLMesh, sqlMeshArgs, process.WithDir(SQLMeshProjectDir))
 		if err != nil {
-			return err
+			return withExitCode(ExitSQLMeshUnavailable, err)
 		}
 
-		sqlmesh.WaitForSQLMeshToStart(baseUrl)
+		if err := sqlmesh.WaitForSQLMeshToStart(baseUrl); err != nil {
+			_ = sqlMeshProcess.Kill()
+			return withExitCode(ExitSQLMeshUnavailable, err)
+		}
+		recordPhase("sqlmesh_start", startedAt)
 
 		err = f(baseUrl)
 		_ = sqlMeshProcess.Kill()
@@ -195,28 +522,71 @@ func WithSQLMesh(f func(baseUrl url.URL) error) error {
 	}
 }
 
+var Strict bool = false
 var SynqApiEndpoint string = "https://developer.synq.io/"
 var SynqApiToken string = os.Getenv("SYNQ_TOKEN")
+var SynqApiCompression bool = true
+var SynqCAFile string
+var SynqClientCertFile string
+var SynqClientKeyFile string
+var GitRemote string = git.DefaultRemote
+var GitFileHistory = false
 var SQLMesh string = "sqlmesh"
 var SQLMeshProjectDir string = "."
+var SQLMeshGateway string = ""
 var SQLMeshUiStart bool = true
 var SQLMeshUiHost string = "localhost"
 var SQLMeshUiPort int = 8080
 var SQLMeshCollectFileContent = false
 var SQLMeshCollectFileContentIncludePattern = "external_models.yaml,models/**/*.sql,models/**/*.py,audits/**/*.sql,tests/**/*.yaml"
 var SQLMeshCollectFileContentExcludePattern = "*.log"
+var SQLMeshConcurrency = 1
+var SQLMeshSelect = ""
+var SQLMeshExclude = ""
 
 func init() {
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return err
+		}
+		if err := configureLogging(); err != nil {
+			return err
+		}
+		if err := startTracing(cmd); err != nil {
+			return err
+		}
+		startRunSummary(cmd)
+		return nil
+	}
+	rootCmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		finishRunSummary(nil)
+		finishTracing(nil)
+	}
+
+	rootCmd.PersistentFlags().StringVar(&ConfigFile, "config", ConfigFile, "Config file, defaults to synq-sqlmesh.yaml in the project directory")
+	rootCmd.PersistentFlags().StringVar(&ConfigProject, "project", ConfigProject, "Name of the project section of the config file to apply")
+	rootCmd.PersistentFlags().BoolVar(&Strict, "strict", Strict, "Exit with non-zero code on collection errors and upload failures")
 	rootCmd.PersistentFlags().StringVar(&SynqApiToken, "synq-token", SynqApiToken, "SYNQ API token")
 	rootCmd.PersistentFlags().StringVar(&SynqApiEndpoint, "synq-endpoint", SynqApiEndpoint, "SYNQ API endpoint URL")
+	rootCmd.PersistentFlags().BoolVar(&SynqApiCompression, "synq-compression", SynqApiCompression, "Compress requests sent to SYNQ API with gzip")
+	rootCmd.PersistentFlags().StringVar(&SynqCAFile, "synq-ca-file", SynqCAFile, "PEM file with additional CA certificates trusted for SYNQ API")
+	rootCmd.PersistentFlags().StringVar(&SynqClientCertFile, "synq-client-cert", SynqClientCertFile, "PEM client certificate presented to SYNQ API (mTLS)")
+	rootCmd.PersistentFlags().StringVar(&SynqClientKeyFile, "synq-client-key", SynqClientKeyFile, "PEM private key of the client certificate (mTLS)")
+	rootCmd.PersistentFlags().StringVar(&GitRemote, "git-remote", GitRemote, "Git remote whose URL identifies the repository")
+	rootCmd.PersistentFlags().BoolVar(&GitFileHistory, "git-file-history", GitFileHistory, "Record last commit of every model file in collect output")
 	rootCmd.PersistentFlags().StringVar(&SQLMesh, "sqlmesh-cmd", SQLMesh, "SQLMesh launcher location")
 	rootCmd.PersistentFlags().StringVar(&SQLMeshProjectDir, "sqlmesh-project-dir", SQLMeshProjectDir, "Location of SQLMesh project directory")
+	rootCmd.PersistentFlags().StringVar(&SQLMeshGateway, "sqlmesh-gateway", SQLMeshGateway, "SQLMesh gateway used by the launched UI, project default if empty")
+	rootCmd.PersistentFlags().StringVar(&SQLMeshVersionCheck, "sqlmesh-version-check", SQLMeshVersionCheck, "Action on unsupported or unknown SQLMesh version: warn, fail or skip")
 	rootCmd.PersistentFlags().BoolVar(&SQLMeshUiStart, "sqlmesh-ui-start", SQLMeshUiStart, "Launch and control SQLMesh UI process automatically")
 	rootCmd.PersistentFlags().StringVar(&SQLMeshUiHost, "sqlmesh-ui-host", SQLMeshUiHost, "SQLMesh UI host")
 	rootCmd.PersistentFlags().IntVar(&SQLMeshUiPort, "sqlmesh-ui-port", SQLMeshUiPort, "SQLMesh UI port")
 	rootCmd.PersistentFlags().BoolVar(&SQLMeshCollectFileContent, "sqlmesh-collect-file-content", SQLMeshCollectFileContent, "If content of the project files should be collected")
 	rootCmd.PersistentFlags().StringVar(&SQLMeshCollectFileContentIncludePattern, "sqlmesh-collect-file-content-include", SQLMeshCollectFileContentIncludePattern, "File patterns to include content")
 	rootCmd.PersistentFlags().StringVar(&SQLMeshCollectFileContentExcludePattern, "sqlmesh-collect-file-content-exclude", SQLMeshCollectFileContentExcludePattern, "File patterns to exclude content")
+	rootCmd.PersistentFlags().IntVar(&SQLMeshConcurrency, "sqlmesh-concurrency", SQLMeshConcurrency, "Number of parallel requests sent to SQLMesh UI")
+	rootCmd.PersistentFlags().StringVar(&SQLMeshSelect, "sqlmesh-select", SQLMeshSelect, "Model name patterns to collect, all models if empty")
+	rootCmd.PersistentFlags().StringVar(&SQLMeshExclude, "sqlmesh-exclude", SQLMeshExclude, "Model name patterns to exclude from collection")
 
 	rootCmd.AddCommand(collectCmd)
 	rootCmd.AddCommand(versionCmd)
@@ -226,6 +596,12 @@ func init() {
 
 }
 
+// Execute runs the command, errors are logged before --log-file is closed.
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	if err != nil {
+		logrus.WithError(err).Error("Error executing command")
+	}
+	closeLogFile()
+	return err
 }
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	VersionCheckWarn = "warn"
+	VersionCheckFail = "fail"
+	VersionCheckSkip = "skip"
+)
+
+var SQLMeshVersionCheck = VersionCheckWarn
+
+// sqlMeshCliVersion runs `sqlmesh --version` in the project directory.
+func sqlMeshCliVersion(ctx context.Context, sqlMeshCmd string) (sqlmesh.Version, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, sqlMeshCmd, "--version")
+	cmd.Dir = SQLMeshProjectDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return sqlmesh.Version{}, fmt.Errorf("sqlmesh --version failed: %s", strings.TrimSpace(string(out)))
+	}
+	return sqlmesh.ParseVersion(string(out))
+}
+
+// detectSQLMeshVersion reads the version from the /api/meta payload of the
+// running UI and falls back to the launcher, then applies
+// --sqlmesh-version-check to the result. A nil meta means /api/meta failed.
+func detectSQLMeshVersion(ctx context.Context, meta json.RawMessage) (sqlmesh.Version, error) {
+	if SQLMeshVersionCheck == VersionCheckSkip {
+		return sqlmesh.Version{}, nil
+	}
+
+	var version sqlmesh.Version
+	err := fmt.Errorf("meta information not available")
+	if meta != nil {
+		version, err = sqlmesh.VersionFromMeta(meta)
+	}
+	if err != nil {
+		logrus.WithError(err).Debug("SQLMesh version not available from /api/meta")
+		version, err = sqlMeshCliVersion(ctx, SQLMesh)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to detect SQLMesh version")
+		}
+	}
+
+	compatibility := sqlmesh.CompatibilityFor(version)
+	switch compatibility.Status {
+	case sqlmesh.CompatibilitySupported:
+		logrus.Infof("SQLMesh version %s", version)
+		return version, nil
+	case sqlmesh.CompatibilityUnsupported:
+		err = fmt.Errorf("SQLMesh %s is not supported: %s", version, compatibility.Note)
+	default:
+		err = fmt.Errorf("SQLMesh compatibility is unknown: %s", compatibility.Note)
+	}
+	if SQLMeshVersionCheck == VersionCheckFail {
+		return version, withExitCode(ExitSQLMeshUnavailable, err)
+	}
+	logrus.Warn(err)
+	return version, nil
+}
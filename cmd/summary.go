@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	sqlmeshv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/sqlmesh/v1"
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var SummaryFile = ""
+
+// RunSummary describes what a command collected and uploaded, it's printed
+// when the command finishes and written to --summary-file.
+type RunSummary struct {
+	Command          string                `json:"command"`
+	Project          string                `json:"project,omitempty"`
+	StartedAt        time.Time             `json:"started_at"`
+	DurationMs       int64                 `json:"duration_ms"`
+	ExitCode         int                   `json:"exit_code"`
+	Error            string                `json:"error,omitempty"`
+	Models           int                   `json:"models"`
+	LineageEntries   int                   `json:"lineage_entries"`
+	Files            int                   `json:"files"`
+	FileContentBytes int                   `json:"file_content_bytes"`
+	LogFiles         int                   `json:"log_files,omitempty"`
+	OutputBytes      int64                 `json:"output_bytes,omitempty"`
+	UploadBytes      int                   `json:"upload_bytes,omitempty"`
+	ImpactReport     *sqlmesh.ImpactReport `json:"impact_report,omitempty"`
+	Errors           []ErrorSummary        `json:"errors"`
+	Phases           []PhaseDuration       `json:"phases"`
+
+	// collected is set once metadata was collected or loaded, commands like
+	// `doctor` or `diff` report no models
+	collected bool
+}
+
+// ErrorSummary counts collection errors of a SQLMesh UI endpoint and status
+// code, errors not caused by an API response have no endpoint.
+type ErrorSummary struct {
+	Endpoint string `json:"endpoint,omitempty"`
+	Status   int64  `json:"status,omitempty"`
+	Count    int    `json:"count"`
+}
+
+type PhaseDuration struct {
+	Phase      string `json:"phase"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// runSummary is nil for commands without a summary, recording into it is a
+// no-op then.
+var runSummary *RunSummary
+
+// startRunSummary starts the summary of every command except help and shell
+// completion of cobra. Subcommands are named with their parent, e.g.
+// `lineage export`.
+func startRunSummary(cmd *cobra.Command) {
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return
+		}
+	}
+	runSummary = &RunSummary{
+		Command:   strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "),
+		StartedAt: time.Now(),
+		Errors:    []ErrorSummary{},
+		Phases:    []PhaseDuration{},
+	}
+}
+
+func recordProject(projectConfig *sqlmesh.ProjectConfig) {
+	if runSummary != nil && projectConfig != nil {
+		runSummary.Project = projectConfig.Project
+	}
+}
+
+// recordPhase records the duration of the phase started at start, it's
+// traced as a span too.
+func recordPhase(phase string, start time.Time) {
+	recordPhaseDuration(phase, time.Since(start))
+	tracePhase(phase, start)
+}
+
+func recordPhaseDuration(phase string, duration time.Duration) {
+	if runSummary == nil {
+		return
+	}
+	runSummary.Phases = append(runSummary.Phases, PhaseDuration{Phase: phase, DurationMs: duration.Milliseconds()})
+}
+
+// recordMetadata records what was collected and groups collection errors.
+func recordMetadata(output *sqlmeshv1.IngestMetadataRequest) {
+	if runSummary == nil || output == nil {
+		return
+	}
+	runSummary.collected = true
+	modelNames, _ := sqlmesh.ModelNames(output.Models)
+	runSummary.Models = len(modelNames)
+	runSummary.LineageEntries = len(output.ModelLineage)
+	runSummary.Files = len(output.FileContent)
+	runSummary.FileContentBytes = 0
+	for _, content := range output.FileContent {
+		runSummary.FileContentBytes += len(content)
+	}
+
+	counts := map[ErrorSummary]int{}
+	for _, apiErr := range output.Errors {
+		counts[ErrorSummary{Endpoint: errorEndpoint(apiErr.GetPath()), Status: apiErr.GetCode()}]++
+	}
+	runSummary.Errors = []ErrorSummary{}
+	for key, count := range counts {
+		key.Count = count
+		runSummary.Errors = append(runSummary.Errors, key)
+	}
+	sort.Slice(runSummary.Errors, func(i, j int) bool {
+		if runSummary.Errors[i].Endpoint != runSummary.Errors[j].Endpoint {
+			return runSummary.Errors[i].Endpoint < runSummary.Errors[j].Endpoint
+		}
+		return runSummary.Errors[i].Status < runSummary.Errors[j].Status
+	})
+}
+
+func recordLogFiles(count int) {
+	if runSummary != nil {
+		runSummary.LogFiles = count
+	}
+}
+
+func recordOutputFile(filename string) {
+	if runSummary == nil {
+		return
+	}
+	if info, err := os.Stat(filename); err == nil {
+		runSummary.OutputBytes = info.Size()
+	}
+}
+
+// recordImpactReport records the impact of --changed-since, nil without it.
+func recordImpactReport(report *sqlmesh.ImpactReport) {
+	if runSummary != nil {
+		runSummary.ImpactReport = report
+	}
+}
+
+func recordUploadSize(size int) {
+	if runSummary != nil {
+		runSummary.UploadBytes = size
+	}
+}
+
+// errorEndpoint strips model names and file paths from the URL of the
+// error, e.g. `/api/lineage/<model>` becomes `/api/lineage`.
+func errorEndpoint(errPath string) string {
+	if errPath == "" {
+		return ""
+	}
+	parsed, err := url.Parse(errPath)
+	if err != nil {
+		return errPath
+	}
+	segments := strings.SplitN(strings.Trim(parsed.Path, "/"), "/", 3)
+	if len(segments) > 2 {
+		segments = segments[:2]
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// finishRunSummary prints the summary, exports its metrics and writes
+// --summary-file, it's called once when the command exits.
+func finishRunSummary(err error) {
+	summary := runSummary
+	if summary == nil {
+		return
+	}
+	runSummary = nil
+
+	summary.DurationMs = time.Since(summary.StartedAt).Milliseconds()
+	summary.ExitCode = ExitCode(err)
+	if err != nil {
+		summary.Error = err.Error()
+	}
+
+	printRunSummary(summary)
+	exportMetrics(summary)
+	if SummaryFile == "" {
+		return
+	}
+	asJson, _ := json.MarshalIndent(summary, "", "  ")
+	if err := os.WriteFile(SummaryFile, append(asJson, '\n'), 0o644); err != nil {
+		logrus.WithError(err).WithField("file", SummaryFile).Error("Failed to write summary file")
+	}
+}
+
+// printRunSummary prints the summary to stderr, keeping stdout for reports
+// like --impact-report.
+func printRunSummary(summary *RunSummary) {
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\nSUMMARY\t%s\n", summary.Command)
+	if summary.Project != "" {
+		fmt.Fprintf(w, "Project\t%s\n", summary.Project)
+	}
+	if summary.LogFiles > 0 {
+		fmt.Fprintf(w, "Log files\t%d\n", summary.LogFiles)
+	} else if summary.collected {
+		fmt.Fprintf(w, "Models\t%d\n", summary.Models)
+		fmt.Fprintf(w, "Lineage entries\t%d\n", summary.LineageEntries)
+		fmt.Fprintf(w, "Files\t%d (%d bytes)\n", summary.Files, summary.FileContentBytes)
+	}
+	if report := summary.ImpactReport; report != nil {
+		fmt.Fprintf(w, "Changed models\t%d (%d downstream) since %s\n", len(report.ChangedModels), len(report.DownstreamModels), report.ChangedSince)
+	}
+	if summary.OutputBytes > 0 {
+		fmt.Fprintf(w, "Output size\t%d bytes\n", summary.OutputBytes)
+	}
+	if summary.UploadBytes > 0 {
+		fmt.Fprintf(w, "Upload size\t%d bytes\n", summary.UploadBytes)
+	}
+	fmt.Fprintf(w, "Duration\t%s\n", time.Duration(summary.DurationMs)*time.Millisecond)
+	fmt.Fprintf(w, "Exit code\t%d\n", summary.ExitCode)
+	if summary.Error != "" {
+		fmt.Fprintf(w, "Error\t%s\n", summary.Error)
+	}
+
+	if len(summary.Phases) > 0 {
+		fmt.Fprintln(w, "\nPHASE\tDURATION")
+		for _, phase := range summary.Phases {
+			fmt.Fprintf(w, "%s\t%s\n", phase.Phase, time.Duration(phase.DurationMs)*time.Millisecond)
+		}
+	}
+
+	if len(summary.Errors) > 0 {
+		fmt.Fprintln(w, "\nENDPOINT\tSTATUS\tERRORS")
+		for _, summaryErr := range summary.Errors {
+			endpoint := summaryErr.Endpoint
+			if endpoint == "" {
+				endpoint = "-"
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\n", endpoint, summaryErr.Status, summaryErr.Count)
+		}
+	}
+	_ = w.Flush()
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&SummaryFile, "summary-file", SummaryFile, "File the run summary of the command is written to as JSON")
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestStartRunSummary(t *testing.T) {
+	rootCmd.InitDefaultHelpCmd()
+	rootCmd.InitDefaultCompletionCmd()
+	findCommand := func(args ...string) *cobra.Command {
+		cmd, _, err := rootCmd.Find(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cmd
+	}
+
+	tests := []struct {
+		args    []string
+		command string
+	}{
+		{[]string{"collect"}, "collect"},
+		{[]string{"upload_run"}, "upload_run"},
+		{[]string{"validate"}, "validate"},
+		{[]string{"diff"}, "diff"},
+		{[]string{"doctor"}, "doctor"},
+		{[]string{"lineage", "export"}, "lineage export"},
+		{[]string{"help"}, ""},
+		{[]string{"completion", "bash"}, ""},
+	}
+	t.Cleanup(func() { runSummary = nil })
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			runSummary = nil
+			startRunSummary(findCommand(tt.args...))
+			command := ""
+			if runSummary != nil {
+				command = runSummary.Command
+			}
+			if command != tt.command {
+				t.Errorf("expected summary of %q, got %q", tt.command, command)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/getsynq/synq-sqlmesh/build"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var OtelEndpoint = ""
+var OtelFile = ""
+
+var tracer = otel.Tracer("github.com/getsynq/synq-sqlmesh/cmd")
+
+// commandSpan is the root span of the command, nil when tracing is off.
+var commandSpan trace.Span
+var commandCtx = context.Background()
+var tracerProvider *sdktrace.TracerProvider
+
+// otlpConfigured reports if OTLP export is requested by --otel-endpoint or
+// the standard OTEL_EXPORTER_OTLP_* environment variables.
+func otlpConfigured() bool {
+	return OtelEndpoint != "" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// startTracing sets up the exporters and starts the root span of the
+// command. The span joins the trace of TRACEPARENT, e.g. set by the
+// orchestrator running synq-sqlmesh.
+func startTracing(cmd *cobra.Command) error {
+	if !otlpConfigured() && OtelFile == "" {
+		return nil
+	}
+
+	var opts []sdktrace.TracerProviderOption
+	if otlpConfigured() {
+		var exporterOpts []otlptracegrpc.Option
+		if strings.Contains(OtelEndpoint, "://") {
+			exporterOpts = append(exporterOpts, otlptracegrpc.WithEndpointURL(OtelEndpoint))
+		} else if OtelEndpoint != "" {
+			exporterOpts = append(exporterOpts, otlptracegrpc.WithEndpoint(OtelEndpoint))
+		}
+		exporter, err := otlptracegrpc.New(cmd.Context(), exporterOpts...)
+		if err != nil {
+			return withExitCode(ExitConfigError, fmt.Errorf("failed to create OTLP exporter: %w", err))
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	if OtelFile != "" {
+		f, err := os.OpenFile(OtelFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return withExitCode(ExitConfigError, fmt.Errorf("failed to open trace file: %w", err))
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			return withExitCode(ExitConfigError, err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	res, err := resource.New(cmd.Context(),
+		resource.WithAttributes(
+			attribute.String("service.name", "synq-sqlmesh"),
+			attribute.String("service.version", strings.TrimSpace(build.Version)),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to detect OpenTelemetry resource")
+	}
+	opts = append(opts, sdktrace.WithResource(res))
+
+	tracerProvider = sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	ctx := otel.GetTextMapPropagator().Extract(cmd.Context(), propagation.MapCarrier{
+		"traceparent": os.Getenv("TRACEPARENT"),
+		"tracestate":  os.Getenv("TRACESTATE"),
+	})
+	commandCtx, commandSpan = tracer.Start(ctx, cmd.CommandPath())
+	cmd.SetContext(commandCtx)
+	return nil
+}
+
+// tracePhase records a finished phase of the command as a child span of the
+// command span.
+func tracePhase(phase string, start time.Time) {
+	if commandSpan == nil {
+		return
+	}
+	_, span := tracer.Start(commandCtx, phase, trace.WithTimestamp(start))
+	span.End()
+}
+
+// finishTracing ends the command span and flushes the exporters.
+func finishTracing(err error) {
+	if commandSpan == nil {
+		return
+	}
+	if err != nil {
+		commandSpan.RecordError(err)
+		commandSpan.SetStatus(codes.Error, err.Error())
+	}
+	commandSpan.SetAttributes(attribute.Int("synq_sqlmesh.exit_code", ExitCode(err)))
+	commandSpan.End()
+	commandSpan = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := tracerProvider.Shutdown(ctx); err != nil {
+		logrus.WithError(err).Warn("Failed to export traces")
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&OtelEndpoint, "otel-endpoint", OtelEndpoint, "OTLP gRPC endpoint spans are exported to, e.g. http://localhost:4317")
+	rootCmd.PersistentFlags().StringVar(&OtelFile, "otel-file", OtelFile, "File spans are appended to as newline delimited JSON")
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/getsynq/synq-sqlmesh/sqlmesh/sqlmeshtest"
+)
+
+// newChangedProject creates a repository with model files of the fixtures
+// where models/orders.sql has uncommitted changes.
+func newChangedProject(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	dir := t.TempDir()
+	writeFile := func(path string, content string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Dev", "GIT_AUTHOR_EMAIL=dev@acme.com",
+			"GIT_COMMITTER_NAME=Dev", "GIT_COMMITTER_EMAIL=dev@acme.com",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	runGit("init", "-q")
+	writeFile("models/orders.sql", "SELECT 1")
+	writeFile("models/customer_revenue.sql", "SELECT 2")
+	runGit("add", "-A")
+	runGit("commit", "-q", "-m", "Add models")
+	writeFile("models/orders.sql", "SELECT 3")
+	return dir
+}
+
+func TestRunUploadRecordsImpactReport(t *testing.T) {
+	projectDir := newChangedProject(t)
+	server := sqlmeshtest.NewServer(mustLoadFixture(t, "sqlmesh-0.130"))
+	defer server.Close()
+	baseUrl := sqlmeshtest.BaseUrl(server)
+	port, err := strconv.Atoi(baseUrl.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, start, host, uiPort := SQLMeshProjectDir, SQLMeshUiStart, SQLMeshUiHost, SQLMeshUiPort
+	since, downstream := ChangedSince, ChangedDownstream
+	t.Cleanup(func() {
+		SQLMeshProjectDir, SQLMeshUiStart, SQLMeshUiHost, SQLMeshUiPort = dir, start, host, uiPort
+		ChangedSince, ChangedDownstream = since, downstream
+		runSummary = nil
+	})
+	SQLMeshProjectDir, SQLMeshUiStart, SQLMeshUiHost, SQLMeshUiPort = projectDir, false, baseUrl.Hostname(), port
+	ChangedSince, ChangedDownstream = "HEAD", true
+	// events are exported instead of uploading to SYNQ
+	setOpenLineage(t, "", filepath.Join(t.TempDir(), "openlineage.ndjson"))
+	startRunSummary(uploadCmd)
+
+	if err := runUpload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	report := runSummary.ImpactReport
+	if report == nil {
+		t.Fatal("expected impact report in the summary")
+	}
+	expected := &sqlmesh.ImpactReport{
+		ChangedSince:  "HEAD",
+		ChangedFiles:  []string{"models/orders.sql"},
+		ChangedModels: []string{"example.orders"},
+		DownstreamModels: []sqlmesh.DownstreamModel{
+			{Name: "example.customer_revenue", ChangedUpstream: []string{"example.orders"}},
+		},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("expected %+v, got %+v", expected, report)
+	}
+}
+
+func mustLoadFixture(t *testing.T, name string) *sqlmeshtest.Fixture {
+	t.Helper()
+	fixture, err := sqlmeshtest.LoadEmbeddedFixture(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fixture
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var ValidateFrom = ""
+var ValidateRules []string
+var ValidateJson = false
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Collect SQLMesh metadata (or load a dump) and report problems without uploading",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		rules, err := sqlmesh.ParseValidationRules(ValidateRules)
+		if err != nil {
+			fmt.Println(err)
+			exit(withExitCode(ExitConfigError, err))
+		}
+
+		output, err := loadOrCollectMetadata(cmd.Context(), ValidateFrom)
+		if err != nil {
+			fmt.Println(err)
+			exit(err)
+		}
+
+		findings := sqlmesh.Validate(output, rules)
+		printFindings(findings)
+
+		errorCount := 0
+		for _, finding := range findings {
+			if finding.Severity == sqlmesh.SeverityError {
+				errorCount++
+			}
+		}
+		if errorCount > 0 {
+			err := fmt.Errorf("validation failed with %d errors", errorCount)
+			logrus.Error(err)
+			exit(withExitCode(ExitValidationFailed, err))
+		}
+		logrus.Infof("Validation passed with %d warnings", len(findings))
+	},
+}
+
+func printFindings(findings []sqlmesh.Finding) {
+	if ValidateJson {
+		if findings == nil {
+			findings = []sqlmesh.Finding{}
+		}
+		asJson, _ := json.MarshalIndent(findings, "", "  ")
+		fmt.Println(string(asJson))
+		return
+	}
+
+	if len(findings) == 0 {
+		fmt.Println("No problems found")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SEVERITY\tRULE\tSUBJECT\tMESSAGE")
+	for _, finding := range findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", strings.ToUpper(string(finding.Severity)), finding.Rule, finding.Subject, finding.Message)
+	}
+	_ = w.Flush()
+}
+
+func init() {
+	validateCmd.Flags().StringVar(&ValidateFrom, "from", ValidateFrom, "Validate a metadata dump created by collect instead of collecting")
+	validateCmd.Flags().StringSliceVar(&ValidateRules, "rules", ValidateRules, "Rule severities as <rule>=error|warning|off, e.g. missing-owner=error,orphan-file=off")
+	validateCmd.Flags().BoolVar(&ValidateJson, "json", ValidateJson, "Print findings as JSON")
+
+	rootCmd.AddCommand(validateCmd)
+}
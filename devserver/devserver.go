@@ -0,0 +1,267 @@
+// Package devserver implements a local stand-in of SYNQ ingest API. It serves
+// the `/oauth2/token` endpoint and `SqlMeshService` on a single plaintext
+// port, records every received request to disk and can inject failures, so
+// the whole collect and upload pipeline can be exercised without a network
+// or a SYNQ account.
+package devserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	ingestsqlmeshv1grpc "buf.build/gen/go/getsynq/api/grpc/go/synq/ingest/sqlmesh/v1/sqlmeshv1grpc"
+	ingestsqlmeshv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/sqlmesh/v1"
+	"github.com/getsynq/synq-sqlmesh/synq"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+type Options struct {
+	// OutputDir is where received requests are written, nothing is written
+	// when empty.
+	OutputDir string
+	// Token is the long-lived token accepted by the token endpoint, any
+	// token is accepted when empty.
+	Token string
+	// RejectToken makes the token endpoint refuse every exchange.
+	RejectToken bool
+	// FailFirst makes the first N ingest calls fail with FailCode.
+	FailFirst int
+	FailCode  codes.Code
+	// Delay is applied before every ingest call is answered.
+	Delay time.Duration
+}
+
+type Server struct {
+	ingestsqlmeshv1grpc.UnimplementedSqlMeshServiceServer
+
+	opts       Options
+	httpServer *http.Server
+	listener   net.Listener
+
+	mu                sync.Mutex
+	calls             int
+	issuedTokens      map[string]bool
+	encodings         []string
+	metadataRequests  []*ingestsqlmeshv1.IngestMetadataRequest
+	executionRequests []*ingestsqlmeshv1.IngestExecutionRequest
+}
+
+func New(opts Options) *Server {
+	if opts.FailCode == codes.OK {
+		opts.FailCode = codes.Unavailable
+	}
+	return &Server{
+		opts:         opts,
+		issuedTokens: make(map[string]bool),
+	}
+}
+
+// Start listens on the address (e.g. `localhost:0`) and serves in the
+// background until Close is called.
+func (s *Server) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if s.opts.OutputDir != "" {
+		if err := os.MkdirAll(s.opts.OutputDir, 0755); err != nil {
+			listener.Close()
+			return err
+		}
+	}
+
+	grpcServer := grpc.NewServer()
+	ingestsqlmeshv1grpc.RegisterSqlMeshServiceServer(grpcServer, s)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth2/token", s.handleToken)
+
+	s.listener = listener
+	s.httpServer = &http.Server{
+		Handler: h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+				s.mu.Lock()
+				s.encodings = append(s.encodings, r.Header.Get("Grpc-Encoding"))
+				s.mu.Unlock()
+				grpcServer.ServeHTTP(w, r)
+				return
+			}
+			mux.ServeHTTP(w, r)
+		}), &http2.Server{}),
+	}
+
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logrus.WithError(err).Error("Dev server stopped")
+		}
+	}()
+	return nil
+}
+
+// URL returns the endpoint to be passed as `--synq-endpoint`.
+func (s *Server) URL() string {
+	return fmt.Sprintf("http://%s/", s.listener.Addr().String())
+}
+
+func (s *Server) Close() error {
+	if s.httpServer == nil {
+		return nil
+	}
+	return s.httpServer.Close()
+}
+
+func (s *Server) MetadataRequests() []*ingestsqlmeshv1.IngestMetadataRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*ingestsqlmeshv1.IngestMetadataRequest{}, s.metadataRequests...)
+}
+
+func (s *Server) ExecutionRequests() []*ingestsqlmeshv1.IngestExecutionRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*ingestsqlmeshv1.IngestExecutionRequest{}, s.executionRequests...)
+}
+
+// Encodings returns the grpc-encoding of every received gRPC call, e.g.
+// `gzip`, empty for uncompressed calls.
+func (s *Server) Encodings() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.encodings...)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	password := r.PostForm.Get("password")
+	if s.opts.RejectToken || password == "" || (s.opts.Token != "" && password != s.opts.Token) {
+		logrus.Warn("Dev server rejected token exchange")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	s.mu.Lock()
+	accessToken := fmt.Sprintf("dev-access-token-%d", len(s.issuedTokens)+1)
+	s.issuedTokens[accessToken] = true
+	s.mu.Unlock()
+
+	logrus.Info("Dev server issued access token")
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	})
+}
+
+func (s *Server) IngestMetadata(ctx context.Context, req *ingestsqlmeshv1.IngestMetadataRequest) (*ingestsqlmeshv1.IngestMetadataResponse, error) {
+	seq, err := s.beginCall(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.metadataRequests = append(s.metadataRequests, req)
+	s.mu.Unlock()
+
+	if s.opts.OutputDir != "" {
+		filename := filepath.Join(s.opts.OutputDir, fmt.Sprintf("%04d-metadata.json", seq))
+		if err := synq.DumpMetadata(req, filename); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		logrus.WithField("path", filename).Info("Dev server recorded metadata request")
+	}
+
+	return &ingestsqlmeshv1.IngestMetadataResponse{}, nil
+}
+
+func (s *Server) IngestExecution(ctx context.Context, req *ingestsqlmeshv1.IngestExecutionRequest) (*ingestsqlmeshv1.IngestExecutionResponse, error) {
+	seq, err := s.beginCall(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.executionRequests = append(s.executionRequests, req)
+	s.mu.Unlock()
+
+	if s.opts.OutputDir != "" {
+		filename := filepath.Join(s.opts.OutputDir, fmt.Sprintf("%04d-execution.json", seq))
+		asJson, err := protojson.MarshalOptions{Multiline: true}.Marshal(req)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if err := os.WriteFile(filename, asJson, 0644); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		logrus.WithField("path", filename).Info("Dev server recorded execution request")
+	}
+
+	return &ingestsqlmeshv1.IngestExecutionResponse{}, nil
+}
+
+// beginCall authenticates the call, applies configured delay and failure
+// injection and returns sequence number of the call.
+func (s *Server) beginCall(ctx context.Context) (int, error) {
+	if err := s.authenticate(ctx); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	s.calls++
+	seq := s.calls
+	s.mu.Unlock()
+
+	if s.opts.Delay > 0 {
+		select {
+		case <-time.After(s.opts.Delay):
+		case <-ctx.Done():
+			return 0, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	if seq <= s.opts.FailFirst {
+		logrus.Warnf("Dev server injected failure %s for call %d", s.opts.FailCode, seq)
+		return 0, status.Errorf(s.opts.FailCode, "injected failure %d of %d", seq, s.opts.FailFirst)
+	}
+
+	return seq, nil
+}
+
+func (s *Server) authenticate(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, authorization := range md.Get("authorization") {
+		accessToken := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+		s.mu.Lock()
+		ok := s.issuedTokens[accessToken]
+		s.mu.Unlock()
+		if ok {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or unknown access token")
+}
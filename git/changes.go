@@ -0,0 +1,44 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangedFiles lists files below the directory changed since the merge base
+// of the ref and HEAD, including uncommitted and untracked files. Paths are
+// relative to the directory and both sides of renames are reported.
+func ChangedFiles(ctx context.Context, dir string, ref string) ([]string, error) {
+	if !commandExists("git") {
+		return nil, fmt.Errorf("git is not available")
+	}
+
+	base, err := runGit(ctx, dir, "merge-base", ref, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("no merge base of %s and HEAD: %w", ref, err)
+	}
+
+	changed := map[string]bool{}
+	diff, err := runGit(ctx, dir, "-c", "core.quotePath=false", "diff", "--name-only", "--relative", "--no-renames", base, "--", ".")
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %w", ref, err)
+	}
+	untracked, err := runGit(ctx, dir, "-c", "core.quotePath=false", "ls-files", "--others", "--exclude-standard", "--", ".")
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files: %w", err)
+	}
+	for _, path := range strings.Split(diff+"\n"+untracked, "\n") {
+		if path != "" {
+			changed[path] = true
+		}
+	}
+
+	res := make([]string, 0, len(changed))
+	for path := range changed {
+		res = append(res, path)
+	}
+	sort.Strings(res)
+	return res, nil
+}
@@ -0,0 +1,159 @@
+package git
+
+import (
+	"os"
+	"strings"
+)
+
+// CIContext is information about the CI build read from environment
+// variables of well-known CI providers. CI checkouts are usually a detached
+// HEAD, the branch of the build is only known from the environment.
+type CIContext struct {
+	Provider    string `json:"provider"`
+	Branch      string `json:"branch,omitempty"`
+	CommitSha   string `json:"commit_sha,omitempty"`
+	PullRequest string `json:"pull_request,omitempty"`
+	BuildUrl    string `json:"build_url,omitempty"`
+	Actor       string `json:"actor,omitempty"`
+}
+
+const (
+	CIGitHubActions  = "github-actions"
+	CIGitLab         = "gitlab-ci"
+	CIBitbucket      = "bitbucket-pipelines"
+	CICircleCI       = "circleci"
+	CIBuildkite      = "buildkite"
+	CIJenkins        = "jenkins"
+	CIAzurePipelines = "azure-pipelines"
+)
+
+// DetectCI returns context of the CI build the process runs in, nil outside
+// of a known CI provider.
+func DetectCI() *CIContext {
+	return detectCI(os.Getenv)
+}
+
+func detectCI(env func(string) string) *CIContext {
+	switch {
+	case env("GITHUB_ACTIONS") == "true":
+		return gitHubActions(env)
+	case env("GITLAB_CI") != "":
+		return &CIContext{
+			Provider:    CIGitLab,
+			Branch:      firstNonEmpty(env("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME"), env("CI_COMMIT_BRANCH")),
+			CommitSha:   env("CI_COMMIT_SHA"),
+			PullRequest: env("CI_MERGE_REQUEST_IID"),
+			BuildUrl:    firstNonEmpty(env("CI_JOB_URL"), env("CI_PIPELINE_URL")),
+			Actor:       env("GITLAB_USER_LOGIN"),
+		}
+	case env("BITBUCKET_BUILD_NUMBER") != "":
+		ci := &CIContext{
+			Provider:    CIBitbucket,
+			Branch:      env("BITBUCKET_BRANCH"),
+			CommitSha:   env("BITBUCKET_COMMIT"),
+			PullRequest: env("BITBUCKET_PR_ID"),
+			Actor:       env("BITBUCKET_STEP_TRIGGERER_UUID"),
+		}
+		if repo := env("BITBUCKET_REPO_FULL_NAME"); repo != "" {
+			ci.BuildUrl = "https://bitbucket.org/" + repo + "/pipelines/results/" + env("BITBUCKET_BUILD_NUMBER")
+		}
+		return ci
+	case env("CIRCLECI") == "true":
+		return &CIContext{
+			Provider:    CICircleCI,
+			Branch:      env("CIRCLE_BRANCH"),
+			CommitSha:   env("CIRCLE_SHA1"),
+			PullRequest: firstNonEmpty(env("CIRCLE_PR_NUMBER"), lastPathSegment(env("CIRCLE_PULL_REQUEST"))),
+			BuildUrl:    env("CIRCLE_BUILD_URL"),
+			Actor:       env("CIRCLE_USERNAME"),
+		}
+	case env("BUILDKITE") == "true":
+		ci := &CIContext{
+			Provider:  CIBuildkite,
+			Branch:    env("BUILDKITE_BRANCH"),
+			CommitSha: env("BUILDKITE_COMMIT"),
+			BuildUrl:  env("BUILDKITE_BUILD_URL"),
+			Actor:     firstNonEmpty(env("BUILDKITE_BUILD_CREATOR"), env("BUILDKITE_BUILD_AUTHOR")),
+		}
+		if pr := env("BUILDKITE_PULL_REQUEST"); pr != "false" {
+			ci.PullRequest = pr
+		}
+		if jobId := env("BUILDKITE_JOB_ID"); ci.BuildUrl != "" && jobId != "" {
+			ci.BuildUrl += "#" + jobId
+		}
+		return ci
+	case env("JENKINS_URL") != "":
+		return &CIContext{
+			Provider:    CIJenkins,
+			Branch:      firstNonEmpty(env("CHANGE_BRANCH"), env("BRANCH_NAME"), strings.TrimPrefix(env("GIT_BRANCH"), "origin/")),
+			CommitSha:   env("GIT_COMMIT"),
+			PullRequest: env("CHANGE_ID"),
+			BuildUrl:    env("BUILD_URL"),
+			Actor:       firstNonEmpty(env("CHANGE_AUTHOR"), env("BUILD_USER_ID")),
+		}
+	case strings.EqualFold(env("TF_BUILD"), "true"):
+		ci := &CIContext{
+			Provider:    CIAzurePipelines,
+			Branch:      strings.TrimPrefix(firstNonEmpty(env("SYSTEM_PULLREQUEST_SOURCEBRANCH"), env("BUILD_SOURCEBRANCH")), "refs/heads/"),
+			CommitSha:   env("BUILD_SOURCEVERSION"),
+			PullRequest: firstNonEmpty(env("SYSTEM_PULLREQUEST_PULLREQUESTNUMBER"), env("SYSTEM_PULLREQUEST_PULLREQUESTID")),
+			Actor:       env("BUILD_REQUESTEDFOR"),
+		}
+		if collection := env("SYSTEM_COLLECTIONURI"); collection != "" && env("BUILD_BUILDID") != "" {
+			ci.BuildUrl = strings.TrimSuffix(collection, "/") + "/" + env("SYSTEM_TEAMPROJECT") + "/_build/results?buildId=" + env("BUILD_BUILDID")
+		}
+		return ci
+	}
+	return nil
+}
+
+func gitHubActions(env func(string) string) *CIContext {
+	ci := &CIContext{
+		Provider:  CIGitHubActions,
+		Branch:    env("GITHUB_HEAD_REF"),
+		CommitSha: env("GITHUB_SHA"),
+		Actor:     firstNonEmpty(env("GITHUB_TRIGGERING_ACTOR"), env("GITHUB_ACTOR")),
+	}
+	ref := env("GITHUB_REF")
+	if ci.Branch == "" && strings.HasPrefix(ref, "refs/heads/") {
+		ci.Branch = strings.TrimPrefix(ref, "refs/heads/")
+	}
+	// pull request refs are `refs/pull/<number>/merge`
+	if strings.HasPrefix(ref, "refs/pull/") {
+		ci.PullRequest = strings.Split(strings.TrimPrefix(ref, "refs/pull/"), "/")[0]
+	}
+	if env("GITHUB_REPOSITORY") != "" && env("GITHUB_RUN_ID") != "" {
+		ci.BuildUrl = firstNonEmpty(env("GITHUB_SERVER_URL"), "https://github.com") + "/" + env("GITHUB_REPOSITORY") + "/actions/runs/" + env("GITHUB_RUN_ID")
+	}
+	return ci
+}
+
+// applyCI fills branch and commit which git could not resolve, e.g. in a
+// detached HEAD checkout.
+func applyCI(ci *CIContext, branch *string, commitSha *string) {
+	if ci == nil {
+		return
+	}
+	if ci.Branch != "" && (*branch == "" || *branch == "HEAD") {
+		*branch = ci.Branch
+	}
+	if *commitSha == "" {
+		*commitSha = ci.CommitSha
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func lastPathSegment(url string) string {
+	if url == "" {
+		return ""
+	}
+	return url[strings.LastIndex(url, "/")+1:]
+}
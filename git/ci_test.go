@@ -0,0 +1,200 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectCI(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected *CIContext
+	}{
+		{
+			name: "no ci",
+			env:  map[string]string{"HOME": "/root"},
+		},
+		{
+			name: "github actions pull request",
+			env: map[string]string{
+				"GITHUB_ACTIONS":          "true",
+				"GITHUB_HEAD_REF":         "feature/orders",
+				"GITHUB_REF":              "refs/pull/42/merge",
+				"GITHUB_SHA":              "abc123",
+				"GITHUB_ACTOR":            "octocat",
+				"GITHUB_TRIGGERING_ACTOR": "hubot",
+				"GITHUB_REPOSITORY":       "acme/analytics",
+				"GITHUB_RUN_ID":           "7",
+			},
+			expected: &CIContext{
+				Provider:    CIGitHubActions,
+				Branch:      "feature/orders",
+				CommitSha:   "abc123",
+				PullRequest: "42",
+				BuildUrl:    "https://github.com/acme/analytics/actions/runs/7",
+				Actor:       "hubot",
+			},
+		},
+		{
+			name: "github actions push on enterprise server",
+			env: map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_REF":        "refs/heads/main",
+				"GITHUB_SHA":        "abc123",
+				"GITHUB_ACTOR":      "octocat",
+				"GITHUB_SERVER_URL": "https://github.acme.com",
+				"GITHUB_REPOSITORY": "acme/analytics",
+				"GITHUB_RUN_ID":     "7",
+			},
+			expected: &CIContext{
+				Provider:  CIGitHubActions,
+				Branch:    "main",
+				CommitSha: "abc123",
+				BuildUrl:  "https://github.acme.com/acme/analytics/actions/runs/7",
+				Actor:     "octocat",
+			},
+		},
+		{
+			name: "gitlab merge request",
+			env: map[string]string{
+				"GITLAB_CI":                           "true",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature/orders",
+				"CI_COMMIT_BRANCH":                    "",
+				"CI_COMMIT_SHA":                       "abc123",
+				"CI_MERGE_REQUEST_IID":                "12",
+				"CI_JOB_URL":                          "https://gitlab.com/acme/analytics/-/jobs/1",
+				"CI_PIPELINE_URL":                     "https://gitlab.com/acme/analytics/-/pipelines/1",
+				"GITLAB_USER_LOGIN":                   "dev",
+			},
+			expected: &CIContext{
+				Provider:    CIGitLab,
+				Branch:      "feature/orders",
+				CommitSha:   "abc123",
+				PullRequest: "12",
+				BuildUrl:    "https://gitlab.com/acme/analytics/-/jobs/1",
+				Actor:       "dev",
+			},
+		},
+		{
+			name: "bitbucket pipelines",
+			env: map[string]string{
+				"BITBUCKET_BUILD_NUMBER":        "5",
+				"BITBUCKET_BRANCH":              "feature/orders",
+				"BITBUCKET_COMMIT":              "abc123",
+				"BITBUCKET_PR_ID":               "3",
+				"BITBUCKET_STEP_TRIGGERER_UUID": "{user}",
+				"BITBUCKET_REPO_FULL_NAME":      "acme/analytics",
+			},
+			expected: &CIContext{
+				Provider:    CIBitbucket,
+				Branch:      "feature/orders",
+				CommitSha:   "abc123",
+				PullRequest: "3",
+				BuildUrl:    "https://bitbucket.org/acme/analytics/pipelines/results/5",
+				Actor:       "{user}",
+			},
+		},
+		{
+			name: "circleci pull request url",
+			env: map[string]string{
+				"CIRCLECI":            "true",
+				"CIRCLE_BRANCH":       "feature/orders",
+				"CIRCLE_SHA1":         "abc123",
+				"CIRCLE_PULL_REQUEST": "https://github.com/acme/analytics/pull/9",
+				"CIRCLE_BUILD_URL":    "https://circleci.com/gh/acme/analytics/1",
+				"CIRCLE_USERNAME":     "dev",
+			},
+			expected: &CIContext{
+				Provider:    CICircleCI,
+				Branch:      "feature/orders",
+				CommitSha:   "abc123",
+				PullRequest: "9",
+				BuildUrl:    "https://circleci.com/gh/acme/analytics/1",
+				Actor:       "dev",
+			},
+		},
+		{
+			name: "buildkite without pull request",
+			env: map[string]string{
+				"BUILDKITE":              "true",
+				"BUILDKITE_BRANCH":       "main",
+				"BUILDKITE_COMMIT":       "abc123",
+				"BUILDKITE_PULL_REQUEST": "false",
+				"BUILDKITE_BUILD_URL":    "https://buildkite.com/acme/analytics/builds/1",
+				"BUILDKITE_JOB_ID":       "job-1",
+				"BUILDKITE_BUILD_AUTHOR": "dev",
+			},
+			expected: &CIContext{
+				Provider:  CIBuildkite,
+				Branch:    "main",
+				CommitSha: "abc123",
+				BuildUrl:  "https://buildkite.com/acme/analytics/builds/1#job-1",
+				Actor:     "dev",
+			},
+		},
+		{
+			name: "jenkins branch",
+			env: map[string]string{
+				"JENKINS_URL":   "https://jenkins.acme.com/",
+				"GIT_BRANCH":    "origin/main",
+				"GIT_COMMIT":    "abc123",
+				"BUILD_URL":     "https://jenkins.acme.com/job/analytics/1/",
+				"BUILD_USER_ID": "dev",
+			},
+			expected: &CIContext{
+				Provider:  CIJenkins,
+				Branch:    "main",
+				CommitSha: "abc123",
+				BuildUrl:  "https://jenkins.acme.com/job/analytics/1/",
+				Actor:     "dev",
+			},
+		},
+		{
+			name: "azure pipelines pull request",
+			env: map[string]string{
+				"TF_BUILD":                             "True",
+				"SYSTEM_PULLREQUEST_SOURCEBRANCH":      "refs/heads/feature/orders",
+				"BUILD_SOURCEBRANCH":                   "refs/pull/4/merge",
+				"BUILD_SOURCEVERSION":                  "abc123",
+				"SYSTEM_PULLREQUEST_PULLREQUESTNUMBER": "4",
+				"BUILD_REQUESTEDFOR":                   "Dev",
+				"SYSTEM_COLLECTIONURI":                 "https://dev.azure.com/acme/",
+				"SYSTEM_TEAMPROJECT":                   "analytics",
+				"BUILD_BUILDID":                        "11",
+			},
+			expected: &CIContext{
+				Provider:    CIAzurePipelines,
+				Branch:      "feature/orders",
+				CommitSha:   "abc123",
+				PullRequest: "4",
+				BuildUrl:    "https://dev.azure.com/acme/analytics/_build/results?buildId=11",
+				Actor:       "Dev",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ci := detectCI(func(key string) string { return tt.env[key] })
+			if !reflect.DeepEqual(ci, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, ci)
+			}
+		})
+	}
+}
+
+func TestApplyCI(t *testing.T) {
+	ci := &CIContext{Provider: CIGitHubActions, Branch: "feature/orders", CommitSha: "abc123"}
+
+	branch, commitSha := "HEAD", ""
+	applyCI(ci, &branch, &commitSha)
+	if branch != "feature/orders" || commitSha != "abc123" {
+		t.Errorf("expected branch and commit of the CI build, got %s %s", branch, commitSha)
+	}
+
+	branch, commitSha = "main", "def456"
+	applyCI(ci, &branch, &commitSha)
+	if branch != "main" || commitSha != "def456" {
+		t.Errorf("expected branch and commit resolved by git to be kept, got %s %s", branch, commitSha)
+	}
+}
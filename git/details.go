@@ -0,0 +1,185 @@
+package git
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	ingestgitv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/git/v1"
+)
+
+// Details describe the checked out code beyond what GitContext of the
+// ingest API can carry. They are stored in metadata dumps and logged.
+type Details struct {
+	CloneUrl   string     `json:"clone_url"`
+	Branch     string     `json:"branch"`
+	CommitSha  string     `json:"commit_sha"`
+	Dirty      bool       `json:"dirty"`
+	DirtyFiles []string   `json:"dirty_files,omitempty"`
+	Author     *Signature `json:"author,omitempty"`
+	Committer  *Signature `json:"committer,omitempty"`
+	CommitTime *time.Time `json:"commit_time,omitempty"`
+	Subject    string     `json:"subject,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Upstream   string     `json:"upstream,omitempty"`
+	CI         *CIContext `json:"ci,omitempty"`
+}
+
+type Signature struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// GitContext returns the part of details sent with the ingest API.
+func (d *Details) GitContext() *ingestgitv1.GitContext {
+	if d == nil {
+		return nil
+	}
+	return &ingestgitv1.GitContext{
+		CloneUrl:  d.CloneUrl,
+		Branch:    d.Branch,
+		CommitSha: d.CommitSha,
+	}
+}
+
+// sqlMeshFileExtensions are extensions of files which affect a SQLMesh
+// project: models, audits, macros, tests, seeds and configuration.
+var sqlMeshFileExtensions = map[string]bool{
+	".sql":  true,
+	".py":   true,
+	".yaml": true,
+	".yml":  true,
+	".csv":  true,
+}
+
+// CollectGitDetails collects details of the repository the directory belongs
+// to. Returns nil when neither git nor CI environment is available, fields
+// which can't be read (e.g. outside of a repository) stay empty.
+func CollectGitDetails(ctx context.Context, dir string, opts ...CollectOpt) *Details {
+	return collect(ctx, dir, true, newCollectOptions(opts))
+}
+
+// collect reads the repository with the git binary, falling back to reading
+// `.git` directly. Without full only clone URL, branch and commit are read.
+func collect(ctx context.Context, dir string, full bool, options *collectOptions) *Details {
+	ci := DetectCI()
+	details := &Details{CI: ci}
+
+	gitAvailable := commandExists("git")
+	found := gitAvailable && collectWithBinary(ctx, dir, details, full, options)
+	if !found {
+		found = collectNative(dir, details, full, options)
+	}
+	if !found && !gitAvailable && ci == nil {
+		return nil
+	}
+
+	applyCI(ci, &details.Branch, &details.CommitSha)
+	return details
+}
+
+func collectWithBinary(ctx context.Context, dir string, details *Details, full bool, options *collectOptions) bool {
+	details.CommitSha = getCommitSHA(ctx, dir)
+	if details.CommitSha == "" {
+		return false
+	}
+	details.CloneUrl = getCloneUrl(ctx, dir, options.remote)
+	details.Branch = getCurrentBranch(ctx, dir)
+	if !full {
+		return true
+	}
+
+	details.Dirty, details.DirtyFiles = getDirtyState(ctx, dir)
+	readCommit(ctx, dir, details)
+	details.Tags = getTags(ctx, dir)
+	details.Upstream, _ = runGit(ctx, dir, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	return true
+}
+
+// collectNative can't read commit metadata nor dirty state, they need
+// objects and the index.
+func collectNative(dir string, details *Details, full bool, options *collectOptions) bool {
+	repo, err := openRepository(dir)
+	if err != nil {
+		return false
+	}
+	branch, sha, err := repo.head()
+	if err != nil || sha == "" {
+		return false
+	}
+	details.Branch = branch
+	details.CommitSha = sha
+	details.CloneUrl = NormalizeRemoteUrl(repo.remoteUrl(options.remote))
+	if !full {
+		return true
+	}
+
+	details.Tags = repo.tagsAt(sha)
+	details.Upstream = repo.upstream(branch)
+	return true
+}
+
+// getDirtyState reports if the work tree has uncommitted changes anywhere in
+// the repository, and which SQLMesh files below the directory are changed.
+// Paths are relative to the directory.
+func getDirtyState(ctx context.Context, dir string) (bool, []string) {
+	out, err := runGitRaw(ctx, dir, "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil || out == "" {
+		return false, nil
+	}
+
+	prefix, _ := runGit(ctx, dir, "rev-parse", "--show-prefix")
+
+	var dirtyFiles []string
+	entries := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		// renames and copies are followed by the original path
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+		path := entry[3:]
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		path = strings.TrimPrefix(path, prefix)
+		if sqlMeshFileExtensions[strings.ToLower(filepath.Ext(path))] {
+			dirtyFiles = append(dirtyFiles, path)
+		}
+	}
+	sort.Strings(dirtyFiles)
+
+	return true, dirtyFiles
+}
+
+func readCommit(ctx context.Context, dir string, details *Details) {
+	out, err := runGit(ctx, dir, "log", "-1", "--format=%an%x00%ae%x00%cn%x00%ce%x00%cI%x00%s", "HEAD")
+	if err != nil {
+		return
+	}
+	fields := strings.Split(out, "\x00")
+	if len(fields) != 6 {
+		return
+	}
+	details.Author = &Signature{Name: fields[0], Email: fields[1]}
+	details.Committer = &Signature{Name: fields[2], Email: fields[3]}
+	if commitTime, err := time.Parse(time.RFC3339, fields[4]); err == nil {
+		details.CommitTime = &commitTime
+	}
+	details.Subject = fields[5]
+}
+
+func getTags(ctx context.Context, dir string) []string {
+	out, err := runGit(ctx, dir, "tag", "--points-at", "HEAD")
+	if err != nil || out == "" {
+		return nil
+	}
+	tags := strings.Split(out, "\n")
+	sort.Strings(tags)
+	return tags
+}
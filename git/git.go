@@ -8,52 +8,43 @@ import (
 	"time"
 )
 
-func CollectGitContext(ctx context.Context, dir string) *ingestgitv1.GitContext {
-	if !commandExists("git") {
-		return nil
-	}
-
-	return &ingestgitv1.GitContext{
-		CloneUrl:  getCloneUrl(ctx, dir),
-		Branch:    getCurrentBranch(ctx, dir),
-		CommitSha: getCommitSHA(ctx, dir),
-	}
+// CollectGitContext collects git context of the directory, branch and commit
+// which git can't resolve are taken from the CI environment.
+func CollectGitContext(ctx context.Context, dir string, opts ...CollectOpt) *ingestgitv1.GitContext {
+	return collect(ctx, dir, false, newCollectOptions(opts)).GitContext()
 }
 
 func getCurrentBranch(ctx context.Context, dir string) string {
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
-	cmd.Dir = dir
-	out, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-	return strings.TrimSpace(string(out))
+	out, _ := runGit(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD")
+	return out
 }
 
 func getCommitSHA(ctx context.Context, dir string) string {
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
-	cmd.Dir = dir
-	out, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-	return strings.TrimSpace(string(out))
+	out, _ := runGit(ctx, dir, "rev-parse", "HEAD")
+	return out
+}
+
+func getCloneUrl(ctx context.Context, dir string, remote string) string {
+	out, _ := runGit(ctx, dir, "remote", "get-url", remote)
+	return NormalizeRemoteUrl(out)
+}
+
+// runGit runs git in the directory and returns its trimmed standard output.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	out, err := runGitRaw(ctx, dir, args...)
+	return strings.TrimSpace(out), err
 }
 
-func getCloneUrl(ctx context.Context, dir string) string {
+func runGitRaw(ctx context.Context, dir string, args ...string) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
-	cmd := exec.CommandContext(ctx, "git", "remote", "get-url", "origin")
+	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = dir
 	out, err := cmd.Output()
 	if err != nil {
-		return ""
+		return "", err
 	}
-	return strings.TrimSpace(string(out))
+	return string(out), nil
 }
 
 func commandExists(cmd string) bool {
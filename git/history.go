@@ -0,0 +1,102 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// FileCommit is the last commit which changed a file.
+type FileCommit struct {
+	CommitSha  string    `json:"commit_sha"`
+	Author     Signature `json:"author"`
+	CommitTime time.Time `json:"commit_time"`
+	Subject    string    `json:"subject,omitempty"`
+}
+
+// commitMarker starts every commit in the `git log` output, file names
+// follow on separate lines.
+const (
+	commitMarker       = "\x00commit\x00"
+	commitMarkerFormat = "%x00commit%x00"
+)
+
+// FileHistory finds the last commit of every path, relative to the
+// directory. A single `git log` walks the history from HEAD and stops once
+// all paths were seen, paths without history (e.g. untracked) are missing
+// from the result.
+func FileHistory(ctx context.Context, dir string, paths []string) (map[string]*FileCommit, error) {
+	res := map[string]*FileCommit{}
+	if len(paths) == 0 {
+		return res, nil
+	}
+	if !commandExists("git") {
+		return nil, fmt.Errorf("git is not available")
+	}
+
+	pending := map[string]bool{}
+	for _, path := range paths {
+		pending[path] = true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.quotePath=false", "log", "--relative", "--no-renames", "--name-only",
+		"--format="+commitMarkerFormat+"%H%x00%an%x00%ae%x00%aI%x00%s", "HEAD", "--", ".")
+	cmd.Dir = dir
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var current *FileCommit
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for len(pending) > 0 && scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, commitMarker) {
+			current = parseFileCommit(strings.TrimPrefix(line, commitMarker))
+			continue
+		}
+		if current == nil || !pending[line] {
+			continue
+		}
+		res[line] = current
+		delete(pending, line)
+	}
+
+	// git blocks writing the rest of the history once the scan stopped,
+	// either because all paths were seen or the output can't be read
+	if len(pending) == 0 || scanner.Err() != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("git log: %w", err)
+		}
+		return res, nil
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	return res, nil
+}
+
+func parseFileCommit(line string) *FileCommit {
+	fields := strings.SplitN(line, "\x00", 5)
+	if len(fields) != 5 {
+		return nil
+	}
+	commit := &FileCommit{
+		CommitSha: fields[0],
+		Author:    Signature{Name: fields[1], Email: fields[2]},
+		Subject:   fields[4],
+	}
+	commit.CommitTime, _ = time.Parse(time.RFC3339, fields[3])
+	return commit
+}
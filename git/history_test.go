@@ -0,0 +1,105 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestRepo creates a repository with a commit per message, each commit
+// writes the files of its message.
+func newTestRepo(t *testing.T, commits ...testCommit) string {
+	t.Helper()
+	if !commandExists("git") {
+		t.Skip("git is not available")
+	}
+	dir := t.TempDir()
+	runTestGit(t, dir, "init", "-q")
+	for _, commit := range commits {
+		for _, path := range commit.files {
+			fullPath := filepath.Join(dir, path)
+			if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(fullPath, []byte(commit.message), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		message := filepath.Join(t.TempDir(), "message")
+		if err := os.WriteFile(message, []byte(commit.message), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		runTestGit(t, dir, "add", "-A")
+		runTestGit(t, dir, "commit", "-q", "-F", message)
+	}
+	return dir
+}
+
+type testCommit struct {
+	message string
+	files   []string
+}
+
+func runTestGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Dev", "GIT_AUTHOR_EMAIL=dev@acme.com",
+		"GIT_COMMITTER_NAME=Dev", "GIT_COMMITTER_EMAIL=dev@acme.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestFileHistory(t *testing.T) {
+	dir := newTestRepo(t,
+		testCommit{message: "Add models", files: []string{"models/orders.sql", "models/customers.sql"}},
+		testCommit{message: "Change orders", files: []string{"models/orders.sql"}},
+		testCommit{message: "Add docs", files: []string{"README.md"}},
+	)
+	head := runTestGit(t, dir, "rev-parse", "HEAD~1")
+	first := runTestGit(t, dir, "rev-parse", "HEAD~2")
+
+	history, err := FileHistory(context.Background(), dir, []string{"models/orders.sql", "models/customers.sql", "models/untracked.sql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected history of 2 files, got %v", history)
+	}
+	orders := history["models/orders.sql"]
+	if orders == nil || orders.CommitSha != head || orders.Subject != "Change orders" {
+		t.Errorf("expected orders changed by %s, got %+v", head, orders)
+	}
+	if orders != nil && (orders.Author != Signature{Name: "Dev", Email: "dev@acme.com"} || orders.CommitTime.IsZero()) {
+		t.Errorf("expected author and commit time, got %+v", orders)
+	}
+	if customers := history["models/customers.sql"]; customers == nil || customers.CommitSha != first {
+		t.Errorf("expected customers added by %s, got %+v", first, customers)
+	}
+}
+
+func TestFileHistoryStopsOnUnreadableOutput(t *testing.T) {
+	// newest commits come first, git blocks writing the rest of the long
+	// subject once the scanner gave up
+	commits := []testCommit{
+		{message: "Add orders", files: []string{"models/orders.sql"}},
+		{message: strings.Repeat("x", 2*1024*1024), files: []string{"docs.md"}},
+	}
+	dir := newTestRepo(t, commits...)
+
+	_, err := FileHistory(context.Background(), dir, []string{"models/orders.sql"})
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("expected %v, got %v", bufio.ErrTooLong, err)
+	}
+}
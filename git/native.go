@@ -0,0 +1,287 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// repository reads git metadata directly from the `.git` directory, it is
+// used when the git binary is not available or can't read the repository
+// (e.g. `safe.directory` restrictions in containers). Only refs and config
+// are read, objects and the index are not.
+type repository struct {
+	// gitDir holds HEAD and per-worktree refs
+	gitDir string
+	// commonDir holds shared refs, packed-refs and config, it differs from
+	// gitDir in linked worktrees
+	commonDir string
+}
+
+// openRepository finds the repository containing the directory, following
+// `gitdir:` files of worktrees and submodules.
+func openRepository(dir string) (*repository, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		candidate := filepath.Join(dir, ".git")
+		if info, err := os.Stat(candidate); err == nil {
+			gitDir := candidate
+			if !info.IsDir() {
+				gitDir, err = readGitDirFile(candidate)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return newRepository(gitDir)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, fmt.Errorf("not a git repository")
+		}
+		dir = parent
+	}
+}
+
+func readGitDirFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(content))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", fmt.Errorf("%s: invalid gitdir file", path)
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+func newRepository(gitDir string) (*repository, error) {
+	if _, err := os.Stat(filepath.Join(gitDir, "HEAD")); err != nil {
+		return nil, fmt.Errorf("%s: not a git directory: %w", gitDir, err)
+	}
+	repo := &repository{gitDir: gitDir, commonDir: gitDir}
+	if content, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(content))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		repo.commonDir = filepath.Clean(commonDir)
+	}
+	return repo, nil
+}
+
+// head returns the current branch (`HEAD` when detached, like
+// `git rev-parse --abbrev-ref HEAD`) and commit.
+func (r *repository) head() (string, string, error) {
+	content, err := os.ReadFile(filepath.Join(r.gitDir, "HEAD"))
+	if err != nil {
+		return "", "", err
+	}
+	head := strings.TrimSpace(string(content))
+	if !strings.HasPrefix(head, "ref:") {
+		return "HEAD", head, nil
+	}
+	ref := strings.TrimSpace(strings.TrimPrefix(head, "ref:"))
+	branch := strings.TrimPrefix(ref, "refs/heads/")
+	sha, err := r.resolveRef(ref)
+	if err != nil {
+		// unborn branch of a fresh repository
+		return branch, "", nil
+	}
+	return branch, sha, nil
+}
+
+// resolveRef resolves loose and packed refs, following symbolic refs.
+func (r *repository) resolveRef(ref string) (string, error) {
+	for depth := 0; depth < 10; depth++ {
+		content, err := r.readLooseRef(ref)
+		if err != nil {
+			packed, err := r.packedRefs()
+			if err != nil {
+				return "", err
+			}
+			sha, ok := packed[ref]
+			if !ok {
+				return "", fmt.Errorf("ref %s not found", ref)
+			}
+			return sha, nil
+		}
+		if !strings.HasPrefix(content, "ref:") {
+			return content, nil
+		}
+		ref = strings.TrimSpace(strings.TrimPrefix(content, "ref:"))
+	}
+	return "", fmt.Errorf("too many levels of symbolic refs")
+}
+
+func (r *repository) readLooseRef(ref string) (string, error) {
+	for _, dir := range []string{r.gitDir, r.commonDir} {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref)))
+		if err == nil {
+			return strings.TrimSpace(string(content)), nil
+		}
+	}
+	return "", fmt.Errorf("ref %s not found", ref)
+}
+
+// packedRefs maps ref names to commits. For annotated tags the peeled
+// commit (`^<sha>` line) is used instead of the tag object.
+func (r *repository) packedRefs() (map[string]string, error) {
+	refs := map[string]string{}
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return refs, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	lastRef := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "^"):
+			if lastRef != "" {
+				refs[lastRef] = strings.TrimPrefix(line, "^")
+			}
+		default:
+			sha, ref, ok := strings.Cut(line, " ")
+			if !ok {
+				continue
+			}
+			refs[ref] = sha
+			lastRef = ref
+		}
+	}
+	return refs, scanner.Err()
+}
+
+// tagsAt returns tags pointing at the commit. Loose annotated tags point to
+// tag objects which are not read, they are only found once packed.
+func (r *repository) tagsAt(sha string) []string {
+	tags := map[string]bool{}
+	if packed, err := r.packedRefs(); err == nil {
+		for ref, refSha := range packed {
+			if refSha == sha && strings.HasPrefix(ref, "refs/tags/") {
+				tags[strings.TrimPrefix(ref, "refs/tags/")] = true
+			}
+		}
+	}
+
+	tagsDir := filepath.Join(r.commonDir, "refs", "tags")
+	_ = filepath.WalkDir(tagsDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || strings.TrimSpace(string(content)) != sha {
+			return nil
+		}
+		name, err := filepath.Rel(tagsDir, path)
+		if err == nil {
+			tags[filepath.ToSlash(name)] = true
+		}
+		return nil
+	})
+
+	if len(tags) == 0 {
+		return nil
+	}
+	res := make([]string, 0, len(tags))
+	for tag := range tags {
+		res = append(res, tag)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// config returns values of the repository config keyed by
+// `section.subsection.key`, e.g. `remote.origin.url`. Includes are not
+// followed.
+func (r *repository) config() (map[string]string, error) {
+	f, err := os.Open(filepath.Join(r.commonDir, "config"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			header := strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			name, subsection, hasSubsection := strings.Cut(header, " ")
+			section = strings.ToLower(name)
+			if hasSubsection {
+				section += "." + strings.Trim(strings.TrimSpace(subsection), `"`)
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(line, "=")
+		values[section+"."+strings.ToLower(strings.TrimSpace(key))] = unquoteConfigValue(strings.TrimSpace(value))
+	}
+	return values, scanner.Err()
+}
+
+func unquoteConfigValue(value string) string {
+	if strings.HasPrefix(value, `"`) {
+		if end := strings.Index(value[1:], `"`); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+	// strip trailing comments of unquoted values
+	if i := strings.IndexAny(value, "#;"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+func (r *repository) remoteUrl(remote string) string {
+	config, err := r.config()
+	if err != nil {
+		return ""
+	}
+	return config["remote."+remote+".url"]
+}
+
+// upstream returns the tracking branch, e.g. `origin/main`.
+func (r *repository) upstream(branch string) string {
+	config, err := r.config()
+	if err != nil || branch == "" || branch == "HEAD" {
+		return ""
+	}
+	remote := config["branch."+branch+".remote"]
+	merge := config["branch."+branch+".merge"]
+	if remote == "" || merge == "" {
+		return ""
+	}
+	if remote == "." {
+		return strings.TrimPrefix(merge, "refs/heads/")
+	}
+	upstream := remote + "/" + strings.TrimPrefix(merge, "refs/heads/")
+	// like git, only report upstream which was fetched
+	if _, err := r.resolveRef("refs/remotes/" + upstream); err != nil {
+		return ""
+	}
+	return upstream
+}
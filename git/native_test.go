@@ -0,0 +1,154 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func assertHead(t *testing.T, repo *repository, branch string, sha string) {
+	t.Helper()
+	gotBranch, gotSha, err := repo.head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotBranch != branch || gotSha != sha {
+		t.Errorf("expected %s at %s, got %s at %s", branch, sha, gotBranch, gotSha)
+	}
+}
+
+func TestRepositoryHead(t *testing.T) {
+	dir := newTestRepo(t, testCommit{message: "Add orders", files: []string{"models/orders.sql"}})
+	branch := runTestGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD")
+	sha := runTestGit(t, dir, "rev-parse", "HEAD")
+
+	// found from a directory below the work tree
+	repo, err := openRepository(filepath.Join(dir, "models"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertHead(t, repo, branch, sha)
+
+	runTestGit(t, dir, "checkout", "-q", "--detach")
+	assertHead(t, repo, "HEAD", sha)
+}
+
+func TestRepositoryUnbornBranch(t *testing.T) {
+	dir := newTestRepo(t)
+	branch := runTestGit(t, dir, "symbolic-ref", "--short", "HEAD")
+
+	repo, err := openRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertHead(t, repo, branch, "")
+}
+
+func TestRepositoryPackedRefs(t *testing.T) {
+	dir := newTestRepo(t,
+		testCommit{message: "Add orders", files: []string{"models/orders.sql"}},
+		testCommit{message: "Change orders", files: []string{"models/orders.sql"}},
+	)
+	branch := runTestGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD")
+	sha := runTestGit(t, dir, "rev-parse", "HEAD")
+	previous := runTestGit(t, dir, "rev-parse", "HEAD~1")
+	runTestGit(t, dir, "tag", "-a", "-m", "Release", "v1.0.0")
+	runTestGit(t, dir, "tag", "v1.0.0-light")
+	runTestGit(t, dir, "update-ref", "refs/remotes/origin/"+branch, previous)
+
+	repo, err := openRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// loose annotated tags point to tag objects which are not read
+	if tags := repo.tagsAt(sha); !reflect.DeepEqual(tags, []string{"v1.0.0-light"}) {
+		t.Errorf("expected the loose lightweight tag, got %v", tags)
+	}
+
+	runTestGit(t, dir, "pack-refs", "--all")
+	if _, err := os.Stat(filepath.Join(dir, ".git", "refs", "heads", branch)); !os.IsNotExist(err) {
+		t.Fatalf("expected refs to be packed, got %v", err)
+	}
+	assertHead(t, repo, branch, sha)
+	if tags := repo.tagsAt(sha); !reflect.DeepEqual(tags, []string{"v1.0.0", "v1.0.0-light"}) {
+		t.Errorf("expected packed tags with the peeled annotated tag, got %v", tags)
+	}
+	if remoteSha, err := repo.resolveRef("refs/remotes/origin/" + branch); err != nil || remoteSha != previous {
+		t.Errorf("expected packed remote branch at %s, got %s: %v", previous, remoteSha, err)
+	}
+
+	// a loose ref wins over the packed one
+	runTestGit(t, dir, "update-ref", "refs/heads/"+branch, previous)
+	assertHead(t, repo, branch, previous)
+}
+
+func TestRepositoryWorktree(t *testing.T) {
+	dir := newTestRepo(t, testCommit{message: "Add orders", files: []string{"models/orders.sql"}})
+	runTestGit(t, dir, "remote", "add", "origin", "https://github.com/acme/shop.git")
+	worktree := filepath.Join(t.TempDir(), "feature")
+	runTestGit(t, dir, "worktree", "add", "-q", "-b", "feature", worktree)
+	runTestGit(t, worktree, "commit", "-q", "--allow-empty", "-m", "Feature")
+	sha := runTestGit(t, worktree, "rev-parse", "HEAD")
+
+	repo, err := openRepository(worktree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.gitDir == repo.commonDir {
+		t.Errorf("expected a common dir of the worktree, got %s", repo.commonDir)
+	}
+	assertHead(t, repo, "feature", sha)
+	// config is shared with the main work tree
+	if remoteUrl := repo.remoteUrl("origin"); remoteUrl != "https://github.com/acme/shop.git" {
+		t.Errorf("expected remote of the main work tree, got %q", remoteUrl)
+	}
+}
+
+func TestRepositoryGitDirFile(t *testing.T) {
+	// submodules have a `.git` file pointing to the repository in the
+	// `.git/modules` directory of the superproject
+	dir := newTestRepo(t, testCommit{message: "Add orders", files: []string{"models/orders.sql"}})
+	branch := runTestGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD")
+	sha := runTestGit(t, dir, "rev-parse", "HEAD")
+	modules := filepath.Join(dir, "..", "modules")
+	if err := os.MkdirAll(modules, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, ".git"), filepath.Join(modules, "shop")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".git"), []byte("gitdir: ../modules/shop\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := openRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, _ := filepath.Abs(filepath.Join(modules, "shop")); repo.gitDir != expected {
+		t.Errorf("expected git dir %s, got %s", expected, repo.gitDir)
+	}
+	assertHead(t, repo, branch, sha)
+}
+
+func TestOpenRepositoryErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := openRepository(dir); err == nil {
+		t.Error("expected error outside of a repository")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".git"), []byte("not a gitdir file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openRepository(dir); err == nil {
+		t.Error("expected error of an invalid gitdir file")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".git"), []byte("gitdir: missing\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openRepository(dir); err == nil {
+		t.Error("expected error of a missing git dir")
+	}
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const DefaultRemote = "origin"
+
+type CollectOpt func(*collectOptions)
+
+type collectOptions struct {
+	remote string
+}
+
+// WithRemote sets the remote whose URL is used as clone URL.
+func WithRemote(remote string) CollectOpt {
+	return func(o *collectOptions) {
+		if remote != "" {
+			o.remote = remote
+		}
+	}
+}
+
+func newCollectOptions(opts []CollectOpt) *collectOptions {
+	options := &collectOptions{remote: DefaultRemote}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// scpLikeRe matches `[user@]host:path` remotes, e.g. `git@github.com:org/repo.git`.
+var scpLikeRe = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// NormalizeRemoteUrl strips credentials of the remote URL and canonicalises
+// SSH, git and HTTP(S) forms of the same repository to
+// `https://<host>/<path>` without the `.git` suffix. Local paths are
+// returned unchanged.
+func NormalizeRemoteUrl(remote string) string {
+	remote = strings.TrimSpace(remote)
+	if remote == "" {
+		return ""
+	}
+
+	var host, path string
+	if strings.Contains(remote, "://") {
+		parsed, err := url.Parse(remote)
+		if err != nil {
+			// credentials can't be stripped reliably
+			return ""
+		}
+		parsed.User = nil
+		switch strings.ToLower(parsed.Scheme) {
+		case "http", "https", "ssh", "git", "git+ssh", "ssh+git":
+		default:
+			return parsed.String()
+		}
+		host = parsed.Hostname()
+		// ports of SSH and HTTPS differ, only non-default HTTP(S) ports are kept
+		if port := parsed.Port(); port != "" && port != "443" && port != "80" && strings.HasPrefix(strings.ToLower(parsed.Scheme), "http") {
+			host += ":" + port
+		}
+		path = parsed.Path
+	} else if m := scpLikeRe.FindStringSubmatch(remote); m != nil && !isLocalPath(remote) {
+		host = m[1]
+		path = m[2]
+	} else {
+		return remote
+	}
+
+	host = strings.ToLower(host)
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+
+	// Azure DevOps uses different paths for SSH and HTTPS remotes
+	if host == "ssh.dev.azure.com" || host == "vs-ssh.visualstudio.com" {
+		parts := strings.Split(strings.TrimPrefix(path, "v3/"), "/")
+		if len(parts) == 3 {
+			return "https://dev.azure.com/" + parts[0] + "/" + parts[1] + "/_git/" + parts[2]
+		}
+	}
+
+	return "https://" + host + "/" + path
+}
+
+func isLocalPath(remote string) bool {
+	// Windows drive letters look like scp hosts
+	if len(remote) > 1 && remote[1] == ':' {
+		return true
+	}
+	return strings.HasPrefix(remote, "/") || strings.HasPrefix(remote, ".") || strings.HasPrefix(remote, "~")
+}
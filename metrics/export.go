@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WriteTextfile writes the families to a file of the node_exporter textfile
+// collector. Samples of the previous file matching the group labels are
+// replaced by the families, other samples, e.g. of other commands or
+// projects, and families not written this time are kept. The file is
+// replaced atomically so the collector never reads a partial file.
+func WriteTextfile(path string, group Labels, families []*Family) error {
+	merged := families
+	if previous, err := os.ReadFile(path); err == nil {
+		merged = mergeFamilies(parseFamilies(bytes.NewReader(previous)), group, families)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	var b bytes.Buffer
+	if err := Write(&b, merged); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b.Bytes()); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// mergeFamilies adds the previous samples to copies of the families unless
+// they belong to the group of a family being written. Previous families not
+// being written are kept as they are.
+func mergeFamilies(previous []*Family, group Labels, families []*Family) []*Family {
+	previousByName := map[string]*Family{}
+	for _, family := range previous {
+		previousByName[family.Name] = family
+	}
+
+	var res []*Family
+	written := map[string]bool{}
+	for _, family := range families {
+		written[family.Name] = true
+		merged := &Family{Name: family.Name, Help: family.Help, Type: family.Type}
+		if previousFamily, ok := previousByName[family.Name]; ok {
+			for _, sample := range previousFamily.Samples {
+				if !sample.Labels.Matches(group) {
+					merged.Samples = append(merged.Samples, sample)
+				}
+			}
+		}
+		merged.Samples = append(merged.Samples, family.Samples...)
+		res = append(res, merged)
+	}
+	for _, family := range previous {
+		if !written[family.Name] {
+			res = append(res, family)
+		}
+	}
+	return res
+}
+
+// Push sends the families to the Pushgateway under the job and group labels.
+// With replace the whole group is replaced (PUT), otherwise only metrics of
+// the same name are (POST), keeping e.g. the last success timestamp of a
+// previous run. Basic auth credentials are taken from the URL.
+func Push(ctx context.Context, pushgatewayUrl string, job string, group Labels, families []*Family, replace bool) error {
+	target, err := url.Parse(strings.TrimSuffix(pushgatewayUrl, "/"))
+	if err != nil {
+		return err
+	}
+	target = target.JoinPath("metrics")
+	target = target.JoinPath(groupingKey("job", job)...)
+	for _, key := range sortedKeys(group) {
+		target = target.JoinPath(groupingKey(key, group[key])...)
+	}
+
+	var b bytes.Buffer
+	if err := Write(&b, families); err != nil {
+		return err
+	}
+
+	method := http.MethodPost
+	if replace {
+		method = http.MethodPut
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), &b)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	_ = res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("Pushgateway %s returned %d: %s", target.Redacted(), res.StatusCode, bytes.TrimSpace(resBody))
+	}
+	return nil
+}
+
+// groupingKey returns the URL path segments of a grouping label. Empty
+// values and values with slashes can't be path segments, they are base64url
+// encoded as `<label>@base64/<value>`.
+func groupingKey(label string, value string) []string {
+	switch {
+	case value == "":
+		return []string{label + "@base64", "="}
+	case strings.Contains(value, "/"):
+		return []string{label + "@base64", base64.RawURLEncoding.EncodeToString([]byte(value))}
+	default:
+		return []string{label, value}
+	}
+}
+
+func sortedKeys(labels Labels) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
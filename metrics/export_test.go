@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteTextfileMerges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "synq_sqlmesh.prom")
+	upload := Labels{"project": "shop", "command": "upload"}
+	collect := Labels{"project": "shop", "command": "collect"}
+
+	if err := WriteTextfile(path, upload, []*Family{
+		Gauge("synq_sqlmesh_exit_code", "Exit code of the last run.", upload, 3),
+		Gauge("synq_sqlmesh_last_success_timestamp_seconds", "Unix time the last successful run finished.", upload, 100),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTextfile(path, collect, []*Family{
+		Gauge("synq_sqlmesh_exit_code", "Exit code of the last run.", collect, 0),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// the failed upload keeps its last success timestamp
+	if err := WriteTextfile(path, upload, []*Family{
+		Gauge("synq_sqlmesh_exit_code", "Exit code of the last run.", upload, 4),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP synq_sqlmesh_exit_code Exit code of the last run.
+# TYPE synq_sqlmesh_exit_code gauge
+synq_sqlmesh_exit_code{command="collect",project="shop"} 0
+synq_sqlmesh_exit_code{command="upload",project="shop"} 4
+# HELP synq_sqlmesh_last_success_timestamp_seconds Unix time the last successful run finished.
+# TYPE synq_sqlmesh_last_success_timestamp_seconds gauge
+synq_sqlmesh_last_success_timestamp_seconds{command="upload",project="shop"} 100
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, content)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected temporary files to be removed, got %v", entries)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("expected a world readable file, got %v %v", info, err)
+	}
+}
+
+type pushRequest struct {
+	method      string
+	path        string
+	contentType string
+	body        string
+}
+
+func startPushgateway(t *testing.T, status int) (string, *[]pushRequest) {
+	t.Helper()
+	var requests []pushRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, pushRequest{
+			method:      r.Method,
+			path:        r.URL.EscapedPath(),
+			contentType: r.Header.Get("Content-Type"),
+			body:        string(body),
+		})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &requests
+}
+
+func TestPush(t *testing.T) {
+	url, requests := startPushgateway(t, http.StatusOK)
+	group := Labels{"project": "shop", "command": "upload"}
+	families := []*Family{Gauge("synq_sqlmesh_exit_code", "Exit code of the last run.", group, 0)}
+
+	if err := Push(context.Background(), url+"/", "synq_sqlmesh", group, families, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := Push(context.Background(), url, "synq_sqlmesh", group, families, false); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedBody := "# HELP synq_sqlmesh_exit_code Exit code of the last run.\n# TYPE synq_sqlmesh_exit_code gauge\nsynq_sqlmesh_exit_code{command=\"upload\",project=\"shop\"} 0\n"
+	expected := []pushRequest{
+		{method: http.MethodPut, path: "/metrics/job/synq_sqlmesh/command/upload/project/shop", contentType: "text/plain; version=0.0.4", body: expectedBody},
+		{method: http.MethodPost, path: "/metrics/job/synq_sqlmesh/command/upload/project/shop", contentType: "text/plain; version=0.0.4", body: expectedBody},
+	}
+	if !reflect.DeepEqual(*requests, expected) {
+		t.Errorf("expected %+v, got %+v", expected, *requests)
+	}
+}
+
+func TestPushEncodesGroupingKey(t *testing.T) {
+	url, requests := startPushgateway(t, http.StatusOK)
+	group := Labels{"project": "", "command": "upload", "dir": "/srv/shop"}
+
+	if err := Push(context.Background(), url, "nightly/sqlmesh", group, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	expected := "/metrics/job@base64/bmlnaHRseS9zcWxtZXNo/command/upload/dir@base64/L3Nydi9zaG9w/project@base64/="
+	if len(*requests) != 1 || (*requests)[0].path != expected {
+		t.Errorf("expected path %s, got %+v", expected, *requests)
+	}
+}
+
+func TestPushFailure(t *testing.T) {
+	url, _ := startPushgateway(t, http.StatusBadRequest)
+
+	err := Push(context.Background(), url, "synq_sqlmesh", Labels{"project": "shop"}, nil, true)
+	if err == nil || !strings.Contains(err.Error(), "returned 400") {
+		t.Errorf("expected status error, got %v", err)
+	}
+}
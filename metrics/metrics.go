@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	TypeGauge = "gauge"
+)
+
+// Family is a metric with its samples in the Prometheus text exposition
+// format.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+type Labels map[string]string
+
+type Sample struct {
+	Labels Labels
+	Value  float64
+}
+
+// Gauge creates a gauge family with a single sample.
+func Gauge(name string, help string, labels Labels, value float64) *Family {
+	return &Family{
+		Name:    name,
+		Help:    help,
+		Type:    TypeGauge,
+		Samples: []Sample{{Labels: labels, Value: value}},
+	}
+}
+
+// Matches reports if the labels contain all the other labels.
+func (l Labels) Matches(other Labels) bool {
+	for key, value := range other {
+		if l[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// With returns a copy of the labels with the label added.
+func (l Labels) With(key string, value string) Labels {
+	res := Labels{}
+	for k, v := range l {
+		res[k] = v
+	}
+	res[key] = value
+	return res
+}
+
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(l))
+	for key := range l {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+`="`+escapeLabelValue(l[key])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var helpUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n")
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+// Write renders the families in the text exposition format, families without
+// samples are skipped.
+func Write(w io.Writer, families []*Family) error {
+	var b bytes.Buffer
+	for _, family := range families {
+		if len(family.Samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n", family.Name, helpEscaper.Replace(family.Help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", family.Name, family.Type)
+		for _, sample := range family.Samples {
+			fmt.Fprintf(&b, "%s%s %s\n", family.Name, sample.Labels, strconv.FormatFloat(sample.Value, 'f', -1, 64))
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// parseFamilies reads families of the text exposition format, lines which
+// can't be parsed are skipped.
+func parseFamilies(r io.Reader) []*Family {
+	var res []*Family
+	byName := map[string]*Family{}
+	family := func(name string) *Family {
+		if f, ok := byName[name]; ok {
+			return f
+		}
+		f := &Family{Name: name, Type: "untyped"}
+		byName[name] = f
+		res = append(res, f)
+		return f
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			parts := strings.SplitN(line, " ", 4)
+			if len(parts) < 3 {
+				continue
+			}
+			switch parts[1] {
+			case "HELP":
+				if len(parts) == 4 {
+					family(parts[2]).Help = helpUnescaper.Replace(parts[3])
+				}
+			case "TYPE":
+				if len(parts) == 4 {
+					family(parts[2]).Type = parts[3]
+				}
+			}
+			continue
+		}
+		name, sample, ok := parseSample(line)
+		if ok {
+			f := family(name)
+			f.Samples = append(f.Samples, sample)
+		}
+	}
+	return res
+}
+
+func parseSample(line string) (string, Sample, bool) {
+	sample := Sample{Labels: Labels{}}
+	nameEnd := strings.IndexAny(line, "{ ")
+	if nameEnd <= 0 {
+		return "", sample, false
+	}
+	name := line[:nameEnd]
+	rest := line[nameEnd:]
+
+	if strings.HasPrefix(rest, "{") {
+		rest = rest[1:]
+		for {
+			rest = strings.TrimLeft(rest, " ,")
+			if strings.HasPrefix(rest, "}") {
+				rest = rest[1:]
+				break
+			}
+			key, value, remaining, ok := parseLabel(rest)
+			if !ok {
+				return "", sample, false
+			}
+			sample.Labels[key] = value
+			rest = remaining
+		}
+	}
+
+	// an optional timestamp may follow the value
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", sample, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", sample, false
+	}
+	sample.Value = value
+	return name, sample, true
+}
+
+func parseLabel(s string) (string, string, string, bool) {
+	eq := strings.Index(s, `="`)
+	if eq <= 0 {
+		return "", "", "", false
+	}
+	key := s[:eq]
+	var value strings.Builder
+	for i := eq + 2; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 >= len(s) {
+				return "", "", "", false
+			}
+			i++
+			if s[i] == 'n' {
+				value.WriteByte('\n')
+			} else {
+				value.WriteByte(s[i])
+			}
+		case '"':
+			return key, value.String(), s[i+1:], true
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+	return "", "", "", false
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	families := []*Family{
+		Gauge("synq_sqlmesh_exit_code", "Exit code of the last run.", Labels{"project": "shop", "command": "upload"}, 3),
+		{Name: "synq_sqlmesh_api_errors", Help: "Collection errors.", Type: TypeGauge},
+		Gauge("synq_sqlmesh_run_duration_seconds", "Duration of the last run.", nil, 1.5),
+	}
+
+	var b bytes.Buffer
+	if err := Write(&b, families); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP synq_sqlmesh_exit_code Exit code of the last run.
+# TYPE synq_sqlmesh_exit_code gauge
+synq_sqlmesh_exit_code{command="upload",project="shop"} 3
+# HELP synq_sqlmesh_run_duration_seconds Duration of the last run.
+# TYPE synq_sqlmesh_run_duration_seconds gauge
+synq_sqlmesh_run_duration_seconds 1.5
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
+
+func TestWriteParseRoundTrip(t *testing.T) {
+	families := []*Family{
+		{
+			Name: "synq_sqlmesh_api_errors",
+			Help: `Errors by endpoint, e.g. C:\path` + "\nsecond line",
+			Type: TypeGauge,
+			Samples: []Sample{
+				{Labels: Labels{"endpoint": "/api/models", "status": "500"}, Value: 2},
+				{Labels: Labels{"endpoint": `quote " backslash \ newline` + "\n", "status": "404"}, Value: 1},
+			},
+		},
+		Gauge("synq_sqlmesh_last_run_timestamp_seconds", "Unix time the last run finished.", Labels{"project": "shop"}, 1760000000),
+		Gauge("synq_sqlmesh_run_duration_seconds", "Duration of the last run.", Labels{}, 0.25),
+	}
+
+	var b bytes.Buffer
+	if err := Write(&b, families); err != nil {
+		t.Fatal(err)
+	}
+	parsed := parseFamilies(&b)
+	if !reflect.DeepEqual(parsed, families) {
+		t.Errorf("expected %+v, got %+v", families, parsed)
+	}
+}
+
+func TestParseFamiliesSkipsInvalidLines(t *testing.T) {
+	input := strings.Join([]string{
+		"# a comment",
+		"# TYPE node_load1 gauge",
+		"node_load1 0.5 1760000000000",
+		`broken{label="unterminated 1`,
+		"no_value",
+		"not_a_number{} abc",
+		"untyped_metric 7",
+	}, "\n")
+
+	expected := []*Family{
+		{Name: "node_load1", Type: TypeGauge, Samples: []Sample{{Labels: Labels{}, Value: 0.5}}},
+		{Name: "untyped_metric", Type: "untyped", Samples: []Sample{{Labels: Labels{}, Value: 7}}},
+	}
+	if parsed := parseFamilies(strings.NewReader(input)); !reflect.DeepEqual(parsed, expected) {
+		t.Errorf("expected %+v, got %+v", expected, parsed)
+	}
+}
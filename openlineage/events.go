@@ -0,0 +1,129 @@
+package openlineage
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// Hand-written subset of the OpenLineage 2-0-2 spec, only the events and
+// facets synq-sqlmesh emits are modeled.
+
+const (
+	Producer = "https://github.com/getsynq/synq-sqlmesh"
+
+	specURL            = "https://openlineage.io/spec/2-0-2/OpenLineage.json"
+	RunEventSchema     = specURL + "#/$defs/RunEvent"
+	JobEventSchema     = specURL + "#/$defs/JobEvent"
+	DatasetEventSchema = specURL + "#/$defs/DatasetEvent"
+	Integration        = "SQLMESH"
+	DefaultNamespace   = "sqlmesh"
+)
+
+const (
+	EventTypeStart    = "START"
+	EventTypeComplete = "COMPLETE"
+	EventTypeFail     = "FAIL"
+)
+
+// Event is a RunEvent, JobEvent or DatasetEvent depending on which fields
+// are set.
+type Event struct {
+	EventType string    `json:"eventType,omitempty"`
+	EventTime time.Time `json:"eventTime"`
+	Run       *Run      `json:"run,omitempty"`
+	Job       *Job      `json:"job,omitempty"`
+	Dataset   *Dataset  `json:"dataset,omitempty"`
+	Inputs    []Dataset `json:"inputs,omitempty"`
+	Outputs   []Dataset `json:"outputs,omitempty"`
+	Producer  string    `json:"producer"`
+	SchemaURL string    `json:"schemaURL"`
+}
+
+type Run struct {
+	RunId  string                 `json:"runId"`
+	Facets map[string]interface{} `json:"facets,omitempty"`
+}
+
+type Job struct {
+	Namespace string                 `json:"namespace"`
+	Name      string                 `json:"name"`
+	Facets    map[string]interface{} `json:"facets,omitempty"`
+}
+
+type Dataset struct {
+	Namespace string                 `json:"namespace"`
+	Name      string                 `json:"name"`
+	Facets    map[string]interface{} `json:"facets,omitempty"`
+}
+
+// Facet carries the fields every OpenLineage facet has.
+type Facet struct {
+	Producer  string `json:"_producer"`
+	SchemaURL string `json:"_schemaURL"`
+}
+
+func newFacet(schemaURL string) Facet {
+	return Facet{Producer: Producer, SchemaURL: schemaURL}
+}
+
+type SchemaField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type SchemaDatasetFacet struct {
+	Facet
+	Fields []SchemaField `json:"fields"`
+}
+
+type DocumentationFacet struct {
+	Facet
+	Description string `json:"description"`
+}
+
+type Owner struct {
+	Name string `json:"name"`
+}
+
+type OwnershipFacet struct {
+	Facet
+	Owners []Owner `json:"owners"`
+}
+
+type JobTypeJobFacet struct {
+	Facet
+	ProcessingType string `json:"processingType"`
+	Integration    string `json:"integration"`
+	JobType        string `json:"jobType"`
+}
+
+type SQLJobFacet struct {
+	Facet
+	Query string `json:"query"`
+}
+
+type ErrorMessageRunFacet struct {
+	Facet
+	Message             string `json:"message"`
+	ProgrammingLanguage string `json:"programmingLanguage"`
+}
+
+func newJobTypeFacet(jobType string) JobTypeJobFacet {
+	return JobTypeJobFacet{
+		Facet:          newFacet("https://openlineage.io/spec/facets/2-0-3/JobTypeJobFacet.json#/$defs/JobTypeJobFacet"),
+		ProcessingType: "BATCH",
+		Integration:    Integration,
+		JobType:        jobType,
+	}
+}
+
+// newRunId returns a random (version 4) UUID.
+func newRunId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
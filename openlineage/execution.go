@@ -0,0 +1,61 @@
+package openlineage
+
+import (
+	"regexp"
+	"strings"
+
+	sqlmeshv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/sqlmesh/v1"
+)
+
+// failureRe matches lines of `sqlmesh run` and `sqlmesh audit` output which
+// mean the command failed. SQLMesh has no machine readable run status and
+// the exit status is not part of the execution log, the output is the only
+// source. Tracebacks are usually written to stderr.
+var failureRe = regexp.MustCompile(`(?m)^.*(Error:|Traceback \(most recent call last\)|Failed processing|Finished with [1-9][0-9]* audit errors?).*$`)
+
+// ExecutionEvents returns START and COMPLETE or FAIL events of the executed
+// command, the job is named after the command, e.g. `sqlmesh.run`.
+func ExecutionEvents(execution *sqlmeshv1.IngestExecutionRequest, namespace string) []*Event {
+	run := &Run{RunId: newRunId()}
+	job := &Job{
+		Namespace: namespace,
+		Name:      strings.Join(execution.Command, "."),
+		Facets: map[string]interface{}{
+			"jobType": newJobTypeFacet("COMMAND"),
+		},
+	}
+
+	start := &Event{
+		EventType: EventTypeStart,
+		EventTime: execution.StartedAt.AsTime(),
+		Run:       run,
+		Job:       job,
+		Producer:  Producer,
+		SchemaURL: RunEventSchema,
+	}
+
+	end := &Event{
+		EventType: EventTypeComplete,
+		EventTime: execution.FinishedAt.AsTime(),
+		Run:       &Run{RunId: run.RunId},
+		Job:       job,
+		Producer:  Producer,
+		SchemaURL: RunEventSchema,
+	}
+	failure := failureRe.Find(execution.StdOut)
+	if failure == nil {
+		failure = failureRe.Find(execution.StdErr)
+	}
+	if failure != nil {
+		end.EventType = EventTypeFail
+		end.Run.Facets = map[string]interface{}{
+			"errorMessage": ErrorMessageRunFacet{
+				Facet:               newFacet("https://openlineage.io/spec/facets/1-0-1/ErrorMessageRunFacet.json#/$defs/ErrorMessageRunFacet"),
+				Message:             strings.TrimSpace(string(failure)),
+				ProgrammingLanguage: "python",
+			},
+		}
+	}
+
+	return []*Event{start, end}
+}
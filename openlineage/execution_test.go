@@ -0,0 +1,89 @@
+package openlineage_test
+
+import (
+	"testing"
+	"time"
+
+	sqlmeshv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/sqlmesh/v1"
+	"github.com/getsynq/synq-sqlmesh/openlineage"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestExecutionEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		stdOut    string
+		stdErr    string
+		eventType string
+		message   string
+	}{
+		{
+			name:      "completed",
+			stdOut:    "Executing model batches ━━━━ 100.0% • 3/3\nAll model batches have been executed successfully\n",
+			eventType: openlineage.EventTypeComplete,
+		},
+		{
+			name:      "error in stdout",
+			stdOut:    "Executing model batches\nError: Failed to execute example.orders\n",
+			eventType: openlineage.EventTypeFail,
+			message:   "Error: Failed to execute example.orders",
+		},
+		{
+			name:      "traceback in stderr",
+			stdOut:    "Executing model batches\n",
+			stdErr:    "Traceback (most recent call last):\n  File \"sqlmesh\", line 8\n",
+			eventType: openlineage.EventTypeFail,
+			message:   "Traceback (most recent call last):",
+		},
+		{
+			name:      "audit errors",
+			stdOut:    "Running audits\nFinished with 2 audit errors.\n",
+			eventType: openlineage.EventTypeFail,
+			message:   "Finished with 2 audit errors.",
+		},
+		{
+			name:      "no audit errors",
+			stdOut:    "Running audits\nFinished with 0 audit errors.\n",
+			eventType: openlineage.EventTypeComplete,
+		},
+	}
+	startedAt := time.Date(2026, 10, 1, 6, 0, 0, 0, time.UTC)
+	finishedAt := startedAt.Add(time.Minute)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execution := &sqlmeshv1.IngestExecutionRequest{
+				Command:    []string{"sqlmesh", "run"},
+				StdOut:     []byte(tt.stdOut),
+				StdErr:     []byte(tt.stdErr),
+				StartedAt:  timestamppb.New(startedAt),
+				FinishedAt: timestamppb.New(finishedAt),
+			}
+
+			events := openlineage.ExecutionEvents(execution, "analytics")
+			if len(events) != 2 {
+				t.Fatalf("expected 2 events, got %d", len(events))
+			}
+			start, end := events[0], events[1]
+			if start.EventType != openlineage.EventTypeStart || !start.EventTime.Equal(startedAt) {
+				t.Errorf("unexpected start event %+v", start)
+			}
+			if end.EventType != tt.eventType || !end.EventTime.Equal(finishedAt) {
+				t.Errorf("expected %s at %s, got %+v", tt.eventType, finishedAt, end)
+			}
+			if start.Run.RunId == "" || start.Run.RunId != end.Run.RunId {
+				t.Errorf("expected the same run id, got %s and %s", start.Run.RunId, end.Run.RunId)
+			}
+			if end.Job.Namespace != "analytics" || end.Job.Name != "sqlmesh.run" {
+				t.Errorf("unexpected job %+v", end.Job)
+			}
+
+			var message string
+			if facet, ok := end.Run.Facets["errorMessage"].(openlineage.ErrorMessageRunFacet); ok {
+				message = facet.Message
+			}
+			if message != tt.message {
+				t.Errorf("expected error message %q, got %q", tt.message, message)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package openlineage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+)
+
+// MetadataEvents describes the collected project. Every SQLMesh model is a
+// job reading its upstream models and writing the dataset of the same name,
+// external models are emitted as DatasetEvents.
+func MetadataEvents(metadata *sqlmesh.Metadata, namespace string, eventTime time.Time) []*Event {
+	var events []*Event
+
+	modelNames := make([]string, 0, len(metadata.Models))
+	for modelName := range metadata.Models {
+		modelNames = append(modelNames, modelName)
+	}
+	sort.Strings(modelNames)
+
+	for _, modelName := range modelNames {
+		model := metadata.Models[modelName]
+		dataset := modelDataset(model, namespace)
+
+		if model.Type.String() == "external" {
+			events = append(events, &Event{
+				EventTime: eventTime,
+				Dataset:   &dataset,
+				Producer:  Producer,
+				SchemaURL: DatasetEventSchema,
+			})
+			continue
+		}
+
+		job := &Job{
+			Namespace: namespace,
+			Name:      modelName,
+			Facets: map[string]interface{}{
+				"jobType": newJobTypeFacet("MODEL"),
+			},
+		}
+		if query := model.Sql.String(); query != "" {
+			job.Facets["sql"] = SQLJobFacet{
+				Facet: newFacet("https://openlineage.io/spec/facets/1-1-0/SQLJobFacet.json#/$defs/SQLJobFacet"),
+				Query: query,
+			}
+		}
+
+		var inputs []Dataset
+		for _, upstream := range metadata.Lineage[modelName] {
+			inputs = append(inputs, Dataset{Namespace: namespace, Name: upstream})
+		}
+
+		events = append(events, &Event{
+			EventTime: eventTime,
+			Job:       job,
+			Inputs:    inputs,
+			Outputs:   []Dataset{dataset},
+			Producer:  Producer,
+			SchemaURL: JobEventSchema,
+		})
+	}
+
+	return events
+}
+
+func modelDataset(model *sqlmesh.Model, namespace string) Dataset {
+	dataset := Dataset{
+		Namespace: namespace,
+		Name:      model.Name,
+		Facets:    map[string]interface{}{},
+	}
+
+	if len(model.Columns) > 0 {
+		schema := SchemaDatasetFacet{
+			Facet: newFacet("https://openlineage.io/spec/facets/1-1-1/SchemaDatasetFacet.json#/$defs/SchemaDatasetFacet"),
+		}
+		for _, column := range model.Columns {
+			schema.Fields = append(schema.Fields, SchemaField{
+				Name:        column.Name,
+				Type:        column.Type.String(),
+				Description: column.Description.String(),
+			})
+		}
+		dataset.Facets["schema"] = schema
+	}
+	if description := model.Description.String(); description != "" {
+		dataset.Facets["documentation"] = DocumentationFacet{
+			Facet:       newFacet("https://openlineage.io/spec/facets/1-0-1/DocumentationDatasetFacet.json#/$defs/DocumentationDatasetFacet"),
+			Description: description,
+		}
+	}
+	if owner := model.Owner(); owner != "" {
+		dataset.Facets["ownership"] = OwnershipFacet{
+			Facet:  newFacet("https://openlineage.io/spec/facets/1-0-1/OwnershipDatasetFacet.json#/$defs/OwnershipDatasetFacet"),
+			Owners: []Owner{{Name: owner}},
+		}
+	}
+	if len(dataset.Facets) == 0 {
+		dataset.Facets = nil
+	}
+
+	return dataset
+}
@@ -0,0 +1,72 @@
+package openlineage_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/getsynq/synq-sqlmesh/openlineage"
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+)
+
+func TestMetadataEvents(t *testing.T) {
+	metadata := &sqlmesh.Metadata{
+		Models: map[string]*sqlmesh.Model{
+			"example.raw_orders": {
+				Name:    "example.raw_orders",
+				Type:    "external",
+				Columns: []sqlmesh.Column{{Name: "order_id", Type: "TEXT"}},
+			},
+			"example.orders": {
+				Name:        "example.orders",
+				Type:        "sql",
+				Columns:     []sqlmesh.Column{{Name: "order_id", Type: "INT", Description: "Order identifier"}},
+				Details:     &sqlmesh.ModelDetails{Owner: "data-team"},
+				Description: "Cleaned orders",
+				Sql:         "SELECT order_id FROM example.raw_orders",
+			},
+		},
+		Lineage: sqlmesh.LineageGraph{
+			"example.orders": {"example.raw_orders"},
+		},
+	}
+	eventTime := time.Date(2026, 10, 1, 6, 0, 0, 0, time.UTC)
+
+	events := openlineage.MetadataEvents(metadata, "analytics", eventTime)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+
+	// models are emitted sorted by name
+	job := events[0]
+	if job.SchemaURL != openlineage.JobEventSchema || job.Job.Name != "example.orders" || !job.EventTime.Equal(eventTime) {
+		t.Errorf("unexpected job event %+v", job)
+	}
+	if sql, ok := job.Job.Facets["sql"].(openlineage.SQLJobFacet); !ok || sql.Query != "SELECT order_id FROM example.raw_orders" {
+		t.Errorf("unexpected sql facet %+v", job.Job.Facets["sql"])
+	}
+	if expected := []openlineage.Dataset{{Namespace: "analytics", Name: "example.raw_orders"}}; !reflect.DeepEqual(job.Inputs, expected) {
+		t.Errorf("expected inputs %+v, got %+v", expected, job.Inputs)
+	}
+	if len(job.Outputs) != 1 || job.Outputs[0].Name != "example.orders" {
+		t.Fatalf("unexpected outputs %+v", job.Outputs)
+	}
+	facets := job.Outputs[0].Facets
+	if schema := facets["schema"].(openlineage.SchemaDatasetFacet); !reflect.DeepEqual(schema.Fields, []openlineage.SchemaField{{Name: "order_id", Type: "INT", Description: "Order identifier"}}) {
+		t.Errorf("unexpected schema %+v", schema.Fields)
+	}
+	if documentation := facets["documentation"].(openlineage.DocumentationFacet); documentation.Description != "Cleaned orders" {
+		t.Errorf("unexpected documentation %+v", documentation)
+	}
+	if ownership := facets["ownership"].(openlineage.OwnershipFacet); !reflect.DeepEqual(ownership.Owners, []openlineage.Owner{{Name: "data-team"}}) {
+		t.Errorf("unexpected ownership %+v", ownership)
+	}
+
+	dataset := events[1]
+	if dataset.SchemaURL != openlineage.DatasetEventSchema || dataset.Job != nil || dataset.Dataset.Name != "example.raw_orders" {
+		t.Errorf("unexpected dataset event %+v", dataset)
+	}
+	if _, ok := dataset.Dataset.Facets["ownership"]; ok {
+		t.Error("expected no ownership facet of the external model")
+	}
+}
@@ -0,0 +1,99 @@
+package openlineage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Transport delivers events to an OpenLineage consumer.
+type Transport interface {
+	Emit(ctx context.Context, events []*Event) error
+}
+
+type fileTransport struct {
+	path string
+}
+
+// NewFileTransport appends events to the file as newline delimited JSON.
+func NewFileTransport(path string) Transport {
+	return &fileTransport{path: path}
+}
+
+func (t *fileTransport) Emit(ctx context.Context, events []*Event) error {
+	f, err := os.OpenFile(t.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encoder := json.NewEncoder(f)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("%s: %w", t.path, err)
+		}
+	}
+	return nil
+}
+
+type httpTransport struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHttpTransport POSTs every event to the endpoint, e.g.
+// `http://marquez:5000/api/v1/lineage`. The api key is sent as bearer token
+// when set.
+func NewHttpTransport(url string, apiKey string) Transport {
+	return &httpTransport{
+		url:    url,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (t *httpTransport) Emit(ctx context.Context, events []*Event) error {
+	for _, event := range events {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if t.apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+t.apiKey)
+		}
+
+		res, err := t.client.Do(req)
+		if err != nil {
+			return err
+		}
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		_ = res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return fmt.Errorf("OpenLineage endpoint %s returned %d: %s", t.url, res.StatusCode, bytes.TrimSpace(resBody))
+		}
+	}
+	return nil
+}
+
+// MultiTransport emits to all transports, stopping at the first error.
+type MultiTransport []Transport
+
+func (m MultiTransport) Emit(ctx context.Context, events []*Event) error {
+	for _, transport := range m {
+		if err := transport.Emit(ctx, events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
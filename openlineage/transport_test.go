@@ -0,0 +1,112 @@
+package openlineage_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getsynq/synq-sqlmesh/openlineage"
+)
+
+func testEvents() []*openlineage.Event {
+	eventTime := time.Date(2026, 10, 1, 6, 0, 0, 0, time.UTC)
+	job := &openlineage.Job{Namespace: "analytics", Name: "sqlmesh.run"}
+	return []*openlineage.Event{
+		{EventType: openlineage.EventTypeStart, EventTime: eventTime, Job: job, Producer: openlineage.Producer, SchemaURL: openlineage.RunEventSchema},
+		{EventType: openlineage.EventTypeComplete, EventTime: eventTime, Job: job, Producer: openlineage.Producer, SchemaURL: openlineage.RunEventSchema},
+	}
+}
+
+func TestFileTransportAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openlineage.ndjson")
+	transport := openlineage.NewFileTransport(path)
+	for i := 0; i < 2; i++ {
+		if err := transport.Emit(context.Background(), testEvents()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var eventTypes []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		event := openlineage.Event{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		eventTypes = append(eventTypes, event.EventType)
+	}
+	if expected := "START,COMPLETE,START,COMPLETE"; strings.Join(eventTypes, ",") != expected {
+		t.Errorf("expected %s, got %v", expected, eventTypes)
+	}
+}
+
+func TestHttpTransport(t *testing.T) {
+	var bodies []string
+	var authorization, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		authorization = r.Header.Get("Authorization")
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	if err := openlineage.NewHttpTransport(server.URL, "secret").Emit(context.Background(), testEvents()); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("expected a request per event, got %d", len(bodies))
+	}
+	if !strings.Contains(bodies[0], `"eventType":"START"`) || !strings.Contains(bodies[1], `"eventType":"COMPLETE"`) {
+		t.Errorf("unexpected bodies %v", bodies)
+	}
+	if authorization != "Bearer secret" || contentType != "application/json" {
+		t.Errorf("unexpected headers %q, %q", authorization, contentType)
+	}
+}
+
+func TestHttpTransportErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "invalid event", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := openlineage.NewHttpTransport(server.URL, "").Emit(context.Background(), testEvents())
+	if err == nil || !strings.Contains(err.Error(), "returned 400: invalid event") {
+		t.Errorf("expected status error, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected emitting to stop at the first error, got %d requests", requests)
+	}
+}
+
+func TestMultiTransport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "openlineage.ndjson")
+	transport := openlineage.MultiTransport{
+		openlineage.NewFileTransport(path),
+		openlineage.NewFileTransport(filepath.Join(dir, "missing", "openlineage.ndjson")),
+	}
+	if err := transport.Emit(context.Background(), testEvents()); err == nil {
+		t.Error("expected error of the missing directory")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected events emitted to the first transport: %s", err)
+	}
+}
@@ -51,13 +51,11 @@ func ExecuteCommand(ctx context.Context, cmdName string, args []string, opts ...
 	}
 	stdOutReader, err := cmd.StdoutPipe()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "error creating StdoutPipe for Cmd", err)
-		os.Exit(0)
+		return nil, fmt.Errorf("error creating StdoutPipe for Cmd: %w", err)
 	}
 	stdErrReader, err := cmd.StderrPipe()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "error creating StderrPipe for Cmd", err)
-		os.Exit(0)
+		return nil, fmt.Errorf("error creating StderrPipe for Cmd: %w", err)
 	}
 
 	var outb, errb bytes.Buffer
@@ -1,13 +1,23 @@
 package sqlmesh
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"strings"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/getsynq/synq-sqlmesh/sqlmesh")
+
 type Api interface {
 	GetMeta() (json.RawMessage, error)
 	GetModels() (json.RawMessage, error)
@@ -33,117 +43,109 @@ type File struct {
 	Content   *string `json:"content,omitempty"`
 }
 
+// FilePaths returns paths of all files in the directory tree.
+func (d *Directory) FilePaths() []string {
+	var res []string
+	for _, file := range d.Files {
+		res = append(res, file.Path)
+	}
+	for i := range d.Directories {
+		res = append(res, d.Directories[i].FilePaths()...)
+	}
+	return res
+}
+
 func NewAPIClient(url url.URL) Api {
+	return newAPIClient(context.Background(), url)
+}
+
+// newAPIClient creates a client tracing requests as children of the span of
+// the context.
+func newAPIClient(ctx context.Context, url url.URL) *ApiImpl {
 	c := &fasthttp.Client{}
 	return &ApiImpl{
 		c:       c,
+		ctx:     ctx,
 		baseUrl: url,
 	}
 }
 
 type ApiImpl struct {
 	c       *fasthttp.Client
+	ctx     context.Context
 	baseUrl url.URL
 }
 
 func (a ApiImpl) Health() (json.RawMessage, error) {
-	urlPath := a.buildUrlPath("health")
-	statusCode, body, err := a.c.Get(nil, urlPath)
-	if err != nil {
-		return nil, err
-	}
-	if statusCode != fasthttp.StatusOK {
-		return nil, a.createStatusError(urlPath, statusCode, body)
-	}
-	return body, nil
+	return a.get(nil, []string{"health"})
 }
 
 func (a ApiImpl) GetMeta() (json.RawMessage, error) {
-	urlPath := a.buildUrlPath("api", "meta")
-	statusCode, body, err := a.c.Get(nil, urlPath)
-	if err != nil {
-		return nil, err
-	}
-	if statusCode != fasthttp.StatusOK {
-		return nil, a.createStatusError(urlPath, statusCode, body)
-	}
-	return body, nil
+	return a.get(nil, []string{"api", "meta"})
 }
 
 func (a ApiImpl) GetModels() (json.RawMessage, error) {
-	urlPath := a.buildUrlPath("api", "models")
-	statusCode, body, err := a.c.Get(nil, urlPath)
-	if err != nil {
-		return nil, err
-	}
-	if statusCode != fasthttp.StatusOK {
-		return nil, a.createStatusError(urlPath, statusCode, body)
-	}
-	return body, nil
+	return a.get(nil, []string{"api", "models"})
 }
 
 func (a ApiImpl) GetModel(modelName string) (json.RawMessage, error) {
-	urlPath := a.buildUrlPath("api", "models", modelName)
-	statusCode, body, err := a.c.Get(nil, urlPath)
-	if err != nil {
-		return nil, err
-	}
-	if statusCode != fasthttp.StatusOK {
-		return nil, a.createStatusError(urlPath, statusCode, body)
-	}
-	return body, nil
+	return a.get(logrus.Fields{"model": modelName}, []string{"api", "models"}, modelName)
 }
 
 func (a ApiImpl) GetLineage(modelName string) (json.RawMessage, error) {
-	urlPath := a.buildUrlPath("api", "lineage", modelName)
-	statusCode, body, err := a.c.Get(nil, urlPath)
-	if err != nil {
-		return nil, err
-	}
-	if statusCode != fasthttp.StatusOK {
-		return nil, a.createStatusError(urlPath, statusCode, body)
-	}
-	return body, nil
+	return a.get(logrus.Fields{"model": modelName}, []string{"api", "lineage"}, modelName)
 }
 
 func (a ApiImpl) GetEnvironments() (json.RawMessage, error) {
-	urlPath := a.buildUrlPath("api", "environments")
-	statusCode, body, err := a.c.Get(nil, urlPath)
-	if err != nil {
-		return nil, err
-	}
-	if statusCode != fasthttp.StatusOK {
-		return nil, a.createStatusError(urlPath, statusCode, body)
-	}
-	return body, nil
+	return a.get(nil, []string{"api", "environments"})
 }
 
 func (a ApiImpl) GetFiles() (json.RawMessage, error) {
-	urlPath := a.buildUrlPath("api", "files")
-	statusCode, body, err := a.c.Get(nil, urlPath)
-	if err != nil {
-		return nil, err
-	}
-	if statusCode != fasthttp.StatusOK {
-		return nil, a.createStatusError(urlPath, statusCode, body)
-	}
-	return body, nil
+	return a.get(nil, []string{"api", "files"})
 }
 
 func (a ApiImpl) GetFileContent(filePath string) (json.RawMessage, error) {
-	urlPath := a.buildUrlPath("api", "files", filePath)
+	return a.get(logrus.Fields{"file": filePath}, []string{"api", "files"}, filePath)
+}
+
+// get fetches the URL and logs the request with its status, duration and
+// the fields on debug level. Requests are traced only within a trace, e.g.
+// health checks while waiting for SQLMesh UI don't start traces of their own.
+func (a ApiImpl) get(fields logrus.Fields, prefix []string, path ...string) (json.RawMessage, error) {
+	urlPath := a.buildUrlPath(prefix, path...)
+	span := trace.SpanFromContext(a.ctx)
+	if a.ctx != nil && span.SpanContext().IsValid() {
+		_, span = tracer.Start(a.ctx, "GET /"+strings.Join(prefix, "/"), trace.WithSpanKind(trace.SpanKindClient))
+		defer span.End()
+		span.SetAttributes(attribute.String("http.request.method", "GET"), attribute.String("url.full", urlPath))
+		for key, value := range fields {
+			span.SetAttributes(attribute.String("sqlmesh."+key, fmt.Sprint(value)))
+		}
+	}
+
+	start := time.Now()
 	statusCode, body, err := a.c.Get(nil, urlPath)
+	log := logrus.WithFields(fields).WithFields(logrus.Fields{
+		"url":         urlPath,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
 	if err != nil {
+		log.WithError(err).Debug("SQLMesh UI request failed")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return nil, err
 	}
+	log.WithField("status", statusCode).Debug("SQLMesh UI request")
+	span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
 	if statusCode != fasthttp.StatusOK {
+		span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", statusCode))
 		return nil, a.createStatusError(urlPath, statusCode, body)
 	}
 	return body, nil
 }
 
-func (a ApiImpl) buildUrlPath(path ...string) string {
-	return a.baseUrl.JoinPath(path...).String()
+func (a ApiImpl) buildUrlPath(prefix []string, path ...string) string {
+	return a.baseUrl.JoinPath(prefix...).JoinPath(path...).String()
 }
 
 type SQLMeshApiError struct {
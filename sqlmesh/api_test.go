@@ -0,0 +1,78 @@
+package sqlmesh_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/getsynq/synq-sqlmesh/sqlmesh/sqlmeshtest"
+)
+
+func TestApiImpl(t *testing.T) {
+	for _, name := range sqlmeshtest.Fixtures() {
+		t.Run(name, func(t *testing.T) {
+			fixture, baseUrl := startFixtureServer(t, name)
+			api := sqlmesh.NewAPIClient(baseUrl)
+
+			calls := map[string]func() (json.RawMessage, error){
+				"/health":           api.Health,
+				"/api/meta":         api.GetMeta,
+				"/api/models":       api.GetModels,
+				"/api/files":        api.GetFiles,
+				"/api/environments": api.GetEnvironments,
+			}
+			for _, modelName := range fixtureModelNames(t, fixture) {
+				calls["/api/models/"+modelName] = func() (json.RawMessage, error) { return api.GetModel(modelName) }
+				calls["/api/lineage/"+modelName] = func() (json.RawMessage, error) { return api.GetLineage(modelName) }
+			}
+			for path := range fixture.Responses {
+				if filePath, ok := strings.CutPrefix(path, "/api/files/"); ok {
+					calls[path] = func() (json.RawMessage, error) { return api.GetFileContent(filePath) }
+				}
+			}
+
+			for path, call := range calls {
+				body, err := call()
+				if err != nil {
+					t.Errorf("%s: %v", path, err)
+					continue
+				}
+				assertJSONEqual(t, path, fixture.Responses[path].Body, body)
+			}
+		})
+	}
+}
+
+func TestApiImplStatusError(t *testing.T) {
+	_, baseUrl := startFixtureServer(t, "sqlmesh-0.96")
+	api := sqlmesh.NewAPIClient(baseUrl)
+
+	_, err := api.GetModel("example.missing")
+	var apiErr *sqlmesh.SQLMeshApiError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected SQLMeshApiError, got %v", err)
+	}
+	if apiErr.Code != 404 {
+		t.Errorf("expected status 404, got %d", apiErr.Code)
+	}
+	if apiErr.UrlPath != baseUrl.JoinPath("api", "models", "example.missing").String() {
+		t.Errorf("unexpected URL %s", apiErr.UrlPath)
+	}
+	if !strings.Contains(apiErr.Message, "Not Found") {
+		t.Errorf("expected response body in message, got %q", apiErr.Message)
+	}
+}
+
+func TestApiImplConnectionError(t *testing.T) {
+	_, baseUrl := startFixtureServer(t, "sqlmesh-0.96")
+	unreachable := baseUrl
+	unreachable.Host = "127.0.0.1:1"
+
+	_, err := sqlmesh.NewAPIClient(unreachable).GetMeta()
+	var apiErr *sqlmesh.SQLMeshApiError
+	if err == nil || errors.As(err, &apiErr) {
+		t.Errorf("expected connection error, got %v", err)
+	}
+}
@@ -1,17 +1,21 @@
 package sqlmesh
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	ingestsqlmeshv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/sqlmesh/v1"
 	"github.com/getsynq/synq-sqlmesh/build"
 	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -24,29 +28,185 @@ func NewSQLMeshMetadata() *ingestsqlmeshv1.IngestMetadataRequest {
 	}
 }
 
-func CollectMetadata(url url.URL, fileContentGlobFilter GlobFilter) (*ingestsqlmeshv1.IngestMetadataRequest, error) {
+type CollectOpt func(*collectOptions)
 
-	api := NewAPIClient(url)
+type collectOptions struct {
+	concurrency   int
+	modelSelector GlobFilter
+	apiMeta       json.RawMessage
+	changedFiles  []string
+	downstream    bool
+	phaseObserver PhaseObserver
+	ctx           context.Context
+}
+
+// PhaseObserver is notified when a phase of the collection finished, phases
+// are meta, models, model_details, files and environments.
+type PhaseObserver func(phase string, duration time.Duration)
+
+// WithApiMeta sets the /api/meta payload fetched beforehand, e.g. to detect
+// the SQLMesh version, so it is not requested again.
+func WithApiMeta(meta json.RawMessage) CollectOpt {
+	return func(o *collectOptions) {
+		o.apiMeta = meta
+	}
+}
+
+// WithConcurrency sets how many requests are sent to SQLMesh UI in parallel
+// when fetching model details, lineage and file content.
+func WithConcurrency(concurrency int) CollectOpt {
+	return func(o *collectOptions) {
+		if concurrency > 0 {
+			o.concurrency = concurrency
+		}
+	}
+}
+
+// WithModelSelector limits collected models to names accepted by the filter.
+func WithModelSelector(modelSelector GlobFilter) CollectOpt {
+	return func(o *collectOptions) {
+		o.modelSelector = modelSelector
+	}
+}
+
+// WithChangedFiles limits collected models to models defined in the files,
+// relative to the project, and with downstream also to models depending on
+// them. Lineage of all selected models is fetched to find the downstream
+// models.
+func WithChangedFiles(changedFiles []string, downstream bool) CollectOpt {
+	return func(o *collectOptions) {
+		o.changedFiles = changedFiles
+		if o.changedFiles == nil {
+			o.changedFiles = []string{}
+		}
+		o.downstream = downstream
+	}
+}
+
+// WithPhaseObserver sets the observer of collection phase durations.
+func WithPhaseObserver(observer PhaseObserver) CollectOpt {
+	return func(o *collectOptions) {
+		o.phaseObserver = observer
+	}
+}
+
+// WithContext sets the context whose span is the parent of the collection
+// and SQLMesh UI request spans.
+func WithContext(ctx context.Context) CollectOpt {
+	return func(o *collectOptions) {
+		o.ctx = ctx
+	}
+}
+
+func CollectMetadata(url url.URL, fileContentGlobFilter GlobFilter, opts ...CollectOpt) (*ingestsqlmeshv1.IngestMetadataRequest, error) {
+	options := &collectOptions{
+		concurrency: 1,
+		ctx:         context.Background(),
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	ctx, span := tracer.Start(options.ctx, "CollectMetadata")
+	defer span.End()
+
+	start := time.Now()
+	phaseStart := start
+	endPhase := func(phase string) {
+		if options.phaseObserver != nil {
+			options.phaseObserver(phase, time.Since(phaseStart))
+		}
+		phaseStart = time.Now()
+	}
+	api := newAPIClient(ctx, url)
 
 	res := NewSQLMeshMetadata()
 	res.UploaderVersion = strings.TrimSpace(fmt.Sprintf("synq-sqlmesh/%s", build.Version))
 	res.UploaderBuildTime = strings.TrimSpace(build.Time)
 
 	var err error
-	res.ApiMeta, err = api.GetMeta()
-	processErr(res, err, "Failed to get meta information")
+	if options.apiMeta != nil {
+		res.ApiMeta = options.apiMeta
+	} else {
+		res.ApiMeta, err = api.GetMeta()
+		processErr(res, err, nil, "Failed to get meta information")
+	}
+	endPhase("meta")
+
 	res.Models, err = api.GetModels()
-	processErr(res, err, "Failed to get models information")
+	processErr(res, err, nil, "Failed to get models information")
 	modelNames, err := ModelNames(res.Models)
-	processErr(res, err, "Failed to get model names")
+	processErr(res, err, nil, "Failed to get model names")
+	if options.modelSelector != nil && err == nil {
+		res.Models, modelNames, err = selectModels(res.Models, options.modelSelector)
+		processErr(res, err, nil, "Failed to select models")
+	}
+
+	var mu sync.Mutex
+	pool := newWorkerPool(options.concurrency)
+	prefetchedLineage := map[string]json.RawMessage{}
+	if options.changedFiles != nil && err == nil {
+		var changed modelSet
+		changed, err = changedModels(res.Models, options.changedFiles)
+		processErr(res, err, nil, "Failed to map changed files to models")
+		if err == nil && options.downstream {
+			// lineage is keyed by fqn, changed models by name
+			models, _ := DecodeModels(res.Models)
+			index := NewModelIndex(models)
+			graph := LineageGraph{}
+			for _, modelName := range modelNames {
+				pool.Go(func() {
+					lineage, err := api.GetLineage(modelName)
+					decoded, decodeErr := DecodeLineage(lineage)
+					mu.Lock()
+					defer mu.Unlock()
+					prefetchedLineage[modelName] = lineage
+					processErr(res, err, logrus.Fields{"model": modelName}, "Failed to get model lineage")
+					if err == nil && decodeErr == nil {
+						graph.Merge(index.Normalize(decoded))
+					}
+				})
+			}
+			pool.Wait()
+			changed = graph.Downstream().Reachable(sortedKeys(changed), 0)
+		}
+		if err == nil {
+			res.Models, modelNames, err = selectModels(res.Models, changed)
+			processErr(res, err, nil, "Failed to select changed models")
+		}
+	}
+	endPhase("models")
+
+	// prefetched lineage is stored before the pool writes to the same map
 	for _, modelName := range modelNames {
-		res.ModelDetails[modelName], err = api.GetModel(modelName)
-		processErr(res, err, "Failed to get model details of %s", modelName)
-		res.ModelLineage[modelName], err = api.GetLineage(modelName)
-		processErr(res, err, "Failed to get model lineage of %s", modelName)
+		if lineage, ok := prefetchedLineage[modelName]; ok {
+			res.ModelLineage[modelName] = lineage
+		}
+	}
+	for _, modelName := range modelNames {
+		pool.Go(func() {
+			details, err := api.GetModel(modelName)
+			mu.Lock()
+			res.ModelDetails[modelName] = details
+			processErr(res, err, logrus.Fields{"model": modelName}, "Failed to get model details")
+			mu.Unlock()
+		})
+		if _, ok := prefetchedLineage[modelName]; ok {
+			continue
+		}
+		pool.Go(func() {
+			lineage, err := api.GetLineage(modelName)
+			mu.Lock()
+			res.ModelLineage[modelName] = lineage
+			processErr(res, err, logrus.Fields{"model": modelName}, "Failed to get model lineage")
+			mu.Unlock()
+		})
 	}
+	pool.Wait()
+	endPhase("model_details")
+
 	res.Files, err = api.GetFiles()
-	processErr(res, err, "Failed to get files information")
+	processErr(res, err, nil, "Failed to get files information")
 
 	if len(res.Files) > 0 {
 		dir := &Directory{}
@@ -56,26 +216,79 @@ func CollectMetadata(url url.URL, fileContentGlobFilter GlobFilter) (*ingestsqlm
 		} else {
 			filesToProcess, err := collectFilesForProcessing(res.Files, fileContentGlobFilter)
 			if err != nil {
-				processErr(res, err, "Failed to collect files for processing")
+				processErr(res, err, nil, "Failed to collect files for processing")
 			} else {
 				for _, fileToProcess := range filesToProcess {
-					fileContent, err := api.GetFileContent(fileToProcess)
-					processErr(res, err, "Failed to get file content %s", fileToProcess)
-					if err == nil {
-						res.FileContent[fileToProcess] = fileContent
-					}
+					pool.Go(func() {
+						fileContent, err := api.GetFileContent(fileToProcess)
+						mu.Lock()
+						defer mu.Unlock()
+						processErr(res, err, logrus.Fields{"file": fileToProcess}, "Failed to get file content")
+						if err == nil {
+							res.FileContent[fileToProcess] = fileContent
+						}
+					})
 				}
+				pool.Wait()
 			}
 		}
 	}
 
+	endPhase("files")
+
 	res.Environments, err = api.GetEnvironments()
-	processErr(res, err, "Failed to get environments information")
+	processErr(res, err, nil, "Failed to get environments information")
+	endPhase("environments")
+
+	span.SetAttributes(
+		attribute.Int("sqlmesh.models", len(modelNames)),
+		attribute.Int("sqlmesh.files", len(res.FileContent)),
+		attribute.Int("sqlmesh.errors", len(res.Errors)),
+	)
+	logrus.WithFields(logrus.Fields{
+		"models":      len(modelNames),
+		"files":       len(res.FileContent),
+		"errors":      len(res.Errors),
+		"duration_ms": time.Since(start).Milliseconds(),
+	}).Info("Collected SQLMesh metadata")
 
 	return res, nil
 }
 
-func processErr(res *ingestsqlmeshv1.IngestMetadataRequest, err error, msg string, args ...interface{}) {
+// selectModels keeps only entries of the /api/models payload accepted by the
+// selector, entries themselves and the shape of the payload are forwarded
+// unchanged.
+func selectModels(models json.RawMessage, modelSelector GlobFilter) (json.RawMessage, []string, error) {
+	payload, err := decodeModelsPayload(models)
+	if err != nil {
+		return models, nil, err
+	}
+
+	var selected []json.RawMessage
+	var modelNames []string
+	for _, entry := range payload.Entries {
+		modelName, ok := entryModelName(entry)
+		if !ok {
+			continue
+		}
+		accepted, err := modelSelector.Match(modelName)
+		if err != nil {
+			return models, nil, err
+		}
+		if accepted {
+			selected = append(selected, entry)
+			modelNames = append(modelNames, modelName)
+		}
+	}
+
+	selectedModels, err := payload.withEntries(selected)
+	if err != nil {
+		return models, nil, err
+	}
+	return selectedModels, modelNames, nil
+}
+
+func processErr(res *ingestsqlmeshv1.IngestMetadataRequest, err error, fields logrus.Fields, msg string) {
 	if err == nil {
 		return
 	}
@@ -92,7 +305,7 @@ func processErr(res *ingestsqlmeshv1.IngestMetadataRequest, err error, msg strin
 		})
 	}
 
-	logError(err, msg, args...)
+	logError(err, fields, msg)
 }
 
 func collectFilesForProcessing(files []byte, fileContentGlobFilter GlobFilter) ([]string, error) {
@@ -114,7 +327,7 @@ func collectFilesForProcessing(files []byte, fileContentGlobFilter GlobFilter) (
 					if errors.Is(err, filepath.ErrBadPattern) {
 						return nil, err
 					}
-					logrus.WithError(err).Error("Failed to match file path")
+					logrus.WithError(err).WithField("file", file.Path).Error("Failed to match file path")
 					continue
 				}
 				if accepted {
@@ -129,30 +342,47 @@ func collectFilesForProcessing(files []byte, fileContentGlobFilter GlobFilter) (
 	return filesToGetContent, nil
 }
 
-func logError(err error, msg string, args ...interface{}) {
-	if err != nil {
-		logrus.Errorf(msg, args...)
-		logrus.Error(err)
+// logError logs the error on a single line, the URL and status code of
+// SQLMesh UI errors are added to the fields.
+func logError(err error, fields logrus.Fields, msg string) {
+	if err == nil {
+		return
 	}
+	log := logrus.WithFields(fields).WithError(err)
+	var sqlMeshApiErr *SQLMeshApiError
+	if errors.As(err, &sqlMeshApiErr) {
+		log = log.WithFields(logrus.Fields{
+			"url":    sqlMeshApiErr.UrlPath,
+			"status": sqlMeshApiErr.Code,
+		})
+	}
+	log.Error(msg)
 }
 
+// ModelNames returns names of the models in the /api/models payload, only
+// the name of every entry has to be decodable.
 func ModelNames(models json.RawMessage) ([]string, error) {
-	type model struct {
-		Name string `json:"name"`
-	}
-
-	var decodedModels []*model
-	err := json.Unmarshal(models, &decodedModels)
+	payload, err := decodeModelsPayload(models)
 	if err != nil {
 		return nil, err
 	}
 
 	var modelNames []string
-	for _, m := range decodedModels {
-		modelName := strings.TrimSpace(m.Name)
-		if len(modelName) > 0 {
+	for _, entry := range payload.Entries {
+		if modelName, ok := entryModelName(entry); ok {
 			modelNames = append(modelNames, modelName)
 		}
 	}
 	return modelNames, nil
 }
+
+func entryModelName(entry json.RawMessage) (string, bool) {
+	model := struct {
+		Name string `json:"name"`
+	}{}
+	if json.Unmarshal(entry, &model) != nil {
+		return "", false
+	}
+	modelName := strings.TrimSpace(model.Name)
+	return modelName, modelName != ""
+}
@@ -0,0 +1,269 @@
+package sqlmesh_test
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/getsynq/synq-sqlmesh/sqlmesh/sqlmeshtest"
+)
+
+const fileContentPattern = "external_models.yaml,models/**/*.sql,audits/**/*.sql"
+
+// startFixtureServer serves the embedded fixture, the fixture is returned so
+// tests can derive expectations from it or change it before requests.
+func startFixtureServer(t *testing.T, name string) (*sqlmeshtest.Fixture, url.URL) {
+	t.Helper()
+	fixture, err := sqlmeshtest.LoadEmbeddedFixture(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := sqlmeshtest.NewServer(fixture)
+	t.Cleanup(server.Close)
+	return fixture, sqlmeshtest.BaseUrl(server)
+}
+
+func fixtureModelNames(t *testing.T, fixture *sqlmeshtest.Fixture) []string {
+	t.Helper()
+	names, err := sqlmesh.ModelNames(fixture.Responses["/api/models"].Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func keys[V any](m map[string]V) []string {
+	res := []string{}
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func assertJSONEqual(t *testing.T, what string, expected json.RawMessage, actual json.RawMessage) {
+	t.Helper()
+	var expectedValue, actualValue interface{}
+	if err := json.Unmarshal(expected, &expectedValue); err != nil {
+		t.Fatalf("%s: invalid expected JSON: %v", what, err)
+	}
+	if err := json.Unmarshal(actual, &actualValue); err != nil {
+		t.Fatalf("%s: invalid JSON %q: %v", what, actual, err)
+	}
+	if !reflect.DeepEqual(expectedValue, actualValue) {
+		t.Errorf("%s: expected %s, got %s", what, expected, actual)
+	}
+}
+
+func TestCollectMetadata(t *testing.T) {
+	if len(sqlmeshtest.Fixtures()) < 2 {
+		t.Fatalf("expected fixtures of multiple SQLMesh versions, got %v", sqlmeshtest.Fixtures())
+	}
+	for _, name := range sqlmeshtest.Fixtures() {
+		t.Run(name, func(t *testing.T) {
+			fixture, baseUrl := startFixtureServer(t, name)
+			if !fixture.Recorded() && fixture.Note == "" {
+				t.Errorf("fixture is neither recorded nor explains its origin")
+			}
+			modelNames := fixtureModelNames(t, fixture)
+
+			for _, concurrency := range []int{1, 4} {
+				res, err := sqlmesh.CollectMetadata(baseUrl, sqlmesh.NewGlobFilter(fileContentPattern, ""), sqlmesh.WithConcurrency(concurrency))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(res.Errors) > 0 {
+					t.Fatalf("unexpected collection errors: %v", res.Errors)
+				}
+
+				version, err := sqlmesh.VersionFromMeta(res.ApiMeta)
+				if err != nil || version.Raw != fixture.SQLMeshVersion {
+					t.Errorf("expected version %s, got %v (%v)", fixture.SQLMeshVersion, version, err)
+				}
+				assertJSONEqual(t, "models", fixture.Responses["/api/models"].Body, res.Models)
+				assertJSONEqual(t, "files", fixture.Responses["/api/files"].Body, res.Files)
+				assertJSONEqual(t, "environments", fixture.Responses["/api/environments"].Body, res.Environments)
+
+				if got := keys(res.ModelDetails); !reflect.DeepEqual(got, modelNames) {
+					t.Errorf("expected details of %v, got %v", modelNames, got)
+				}
+				if got := keys(res.ModelLineage); !reflect.DeepEqual(got, modelNames) {
+					t.Errorf("expected lineage of %v, got %v", modelNames, got)
+				}
+				for _, modelName := range modelNames {
+					assertJSONEqual(t, "details of "+modelName, fixture.Responses["/api/models/"+modelName].Body, res.ModelDetails[modelName])
+					assertJSONEqual(t, "lineage of "+modelName, fixture.Responses["/api/lineage/"+modelName].Body, res.ModelLineage[modelName])
+				}
+
+				expectedFiles, err := sqlmesh.CollectFilesForProcessing(res.Files, sqlmesh.NewGlobFilter(fileContentPattern, ""))
+				if err != nil {
+					t.Fatal(err)
+				}
+				sort.Strings(expectedFiles)
+				if got := keys(res.FileContent); len(got) == 0 || !reflect.DeepEqual(got, expectedFiles) {
+					t.Errorf("expected content of %v, got %v", expectedFiles, got)
+				}
+				for _, path := range expectedFiles {
+					assertJSONEqual(t, "content of "+path, fixture.Responses["/api/files/"+path].Body, res.FileContent[path])
+				}
+			}
+		})
+	}
+}
+
+func TestCollectMetadataRecordsErrors(t *testing.T) {
+	fixture, baseUrl := startFixtureServer(t, "sqlmesh-0.96")
+	fixture.Responses["/api/lineage/example.orders"] = sqlmeshtest.Response{Status: 500, Text: "Internal Server Error"}
+	delete(fixture.Responses, "/api/environments")
+
+	res, err := sqlmesh.CollectMetadata(baseUrl, sqlmesh.NewExcludeEverythingGlobFilter())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes := map[string]int64{}
+	for _, apiErr := range res.Errors {
+		parsed, _ := url.Parse(apiErr.GetPath())
+		codes[parsed.Path] = apiErr.GetCode()
+	}
+	expected := map[string]int64{
+		"/api/lineage/example.orders": 500,
+		"/api/environments":           404,
+	}
+	if !reflect.DeepEqual(codes, expected) {
+		t.Errorf("expected errors %v, got %v", expected, codes)
+	}
+	// the other models are still collected
+	if len(res.ModelLineage["example.customer_revenue"]) == 0 {
+		t.Error("expected lineage of example.customer_revenue")
+	}
+	if len(res.FileContent) != 0 {
+		t.Errorf("expected no file content, got %v", keys(res.FileContent))
+	}
+}
+
+func TestCollectMetadataWithApiMeta(t *testing.T) {
+	fixture, baseUrl := startFixtureServer(t, "sqlmesh-0.130")
+	meta := fixture.Responses["/api/meta"].Body
+	// meta fetched beforehand is not requested again
+	delete(fixture.Responses, "/api/meta")
+
+	res, err := sqlmesh.CollectMetadata(baseUrl, sqlmesh.NewExcludeEverythingGlobFilter(), sqlmesh.WithApiMeta(meta))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("unexpected collection errors: %v", res.Errors)
+	}
+	assertJSONEqual(t, "meta", meta, res.ApiMeta)
+}
+
+func TestCollectMetadataModelSelector(t *testing.T) {
+	_, baseUrl := startFixtureServer(t, "sqlmesh-0.130")
+
+	res, err := sqlmesh.CollectMetadata(baseUrl, sqlmesh.NewExcludeEverythingGlobFilter(),
+		sqlmesh.WithModelSelector(sqlmesh.NewGlobFilter("example.*", "example.raw_*,example.country_codes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"example.customer_revenue", "example.orders"}
+	names, err := sqlmesh.ModelNames(res.Models)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected models %v, got %v", expected, names)
+	}
+	if got := keys(res.ModelDetails); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected details of %v, got %v", expected, got)
+	}
+}
+
+func TestCollectFilesForProcessing(t *testing.T) {
+	fixture, err := sqlmeshtest.LoadEmbeddedFixture("sqlmesh-0.130")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := fixture.Responses["/api/files"].Body
+
+	tests := []struct {
+		name     string
+		filter   sqlmesh.GlobFilter
+		expected []string
+	}{
+		{
+			name:     "nothing",
+			filter:   sqlmesh.NewExcludeEverythingGlobFilter(),
+			expected: nil,
+		},
+		{
+			name:     "everything",
+			filter:   sqlmesh.NewGlobFilter("**", ""),
+			expected: []string{"audits/assert_positive_revenue.sql", "config.yaml", "external_models.yaml", "models/customer_revenue.sql", "models/orders.sql", "seeds/country_codes.csv"},
+		},
+		{
+			name:     "include and exclude",
+			filter:   sqlmesh.NewGlobFilter("models/**/*.sql,*.yaml", "config.yaml"),
+			expected: []string{"external_models.yaml", "models/customer_revenue.sql", "models/orders.sql"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sqlmesh.CollectFilesForProcessing(files, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := sqlmesh.CollectFilesForProcessing(files, sqlmesh.NewGlobFilter("**", "[")); err == nil {
+		t.Error("expected bad pattern error")
+	}
+	if _, err := sqlmesh.CollectFilesForProcessing([]byte(`[]`), sqlmesh.NewGlobFilter("**", "")); err == nil {
+		t.Error("expected error for payload which is not a directory")
+	}
+}
+
+func TestCollectMetadataModelSelectorKeepsEnvelope(t *testing.T) {
+	fixture, baseUrl := startFixtureServer(t, "sqlmesh-0.96")
+	models := fixture.Responses["/api/models"]
+	models.Body = json.RawMessage(`{"models":` + string(models.Body) + `,"total":3}`)
+	fixture.Responses["/api/models"] = models
+
+	res, err := sqlmesh.CollectMetadata(baseUrl, sqlmesh.NewExcludeEverythingGlobFilter(),
+		sqlmesh.WithModelSelector(sqlmesh.NewGlobFilter("example.orders", "")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope := struct {
+		Models []json.RawMessage `json:"models"`
+		Total  int               `json:"total"`
+	}{}
+	if err := json.Unmarshal(res.Models, &envelope); err != nil {
+		t.Fatalf("expected models in an envelope, got %s", res.Models)
+	}
+	if len(envelope.Models) != 1 || envelope.Total != 3 {
+		t.Errorf("expected one model and other keys kept, got %s", res.Models)
+	}
+	decoded, err := sqlmesh.DecodeModels(res.Models)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || decoded[0].Name != "example.orders" {
+		t.Errorf("expected example.orders, got %s", res.Models)
+	}
+	if got := keys(res.ModelDetails); !reflect.DeepEqual(got, []string{"example.orders"}) {
+		t.Errorf("expected details of example.orders, got %v", got)
+	}
+}
@@ -0,0 +1,55 @@
+package sqlmesh
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type CompatibilityStatus string
+
+const (
+	CompatibilitySupported   CompatibilityStatus = "supported"
+	CompatibilityUntested    CompatibilityStatus = "untested"
+	CompatibilityUnsupported CompatibilityStatus = "unsupported"
+)
+
+// Compatibility tells if a SQLMesh version is supported. Every supported
+// version is collected from the same SQLMesh UI endpoints, versions are only
+// gated.
+type Compatibility struct {
+	Status CompatibilityStatus
+	Note   string
+}
+
+// CompatibilityFor returns compatibility of the version, versions since
+// MinSupportedVersion are supported and unknown (zero) version is untested.
+func CompatibilityFor(v Version) Compatibility {
+	switch {
+	case v == (Version{}):
+		return Compatibility{
+			Status: CompatibilityUntested,
+			Note:   "SQLMesh version could not be detected",
+		}
+	case v.Compare(MinSupportedVersion) < 0:
+		return Compatibility{
+			Status: CompatibilityUnsupported,
+			Note:   fmt.Sprintf("SQLMesh older than %s is not supported", MinSupportedVersion),
+		}
+	default:
+		return Compatibility{Status: CompatibilitySupported}
+	}
+}
+
+// VersionFromMeta reads SQLMesh version from the /api/meta payload.
+func VersionFromMeta(meta json.RawMessage) (Version, error) {
+	fields := struct {
+		Version string `json:"version"`
+	}{}
+	if err := json.Unmarshal(meta, &fields); err != nil {
+		return Version{}, err
+	}
+	if fields.Version == "" {
+		return Version{}, fmt.Errorf("version missing in meta information")
+	}
+	return ParseVersion(fields.Version)
+}
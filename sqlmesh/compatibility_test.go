@@ -0,0 +1,56 @@
+package sqlmesh_test
+
+import (
+	"testing"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/getsynq/synq-sqlmesh/sqlmesh/sqlmeshtest"
+)
+
+func TestCompatibilityFor(t *testing.T) {
+	tests := []struct {
+		version string
+		status  sqlmesh.CompatibilityStatus
+	}{
+		{"", sqlmesh.CompatibilityUntested},
+		{"0.57.2", sqlmesh.CompatibilityUnsupported},
+		{"0.95.9", sqlmesh.CompatibilityUnsupported},
+		{"0.96.0", sqlmesh.CompatibilitySupported},
+		{"0.130.1", sqlmesh.CompatibilitySupported},
+		{"1.2.0", sqlmesh.CompatibilitySupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			var version sqlmesh.Version
+			if tt.version != "" {
+				var err error
+				if version, err = sqlmesh.ParseVersion(tt.version); err != nil {
+					t.Fatal(err)
+				}
+			}
+			compatibility := sqlmesh.CompatibilityFor(version)
+			if compatibility.Status != tt.status {
+				t.Errorf("expected %s, got %s", tt.status, compatibility.Status)
+			}
+			if tt.status != sqlmesh.CompatibilitySupported && compatibility.Note == "" {
+				t.Error("expected a note")
+			}
+		})
+	}
+}
+
+func TestCompatibilityForFixtures(t *testing.T) {
+	for _, name := range sqlmeshtest.Fixtures() {
+		fixture, err := sqlmeshtest.LoadEmbeddedFixture(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		version, err := sqlmesh.ParseVersion(fixture.SQLMeshVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status := sqlmesh.CompatibilityFor(version).Status; status != sqlmesh.CompatibilitySupported {
+			t.Errorf("%s: expected recorded version %s to be supported, got %s", name, version, status)
+		}
+	}
+}
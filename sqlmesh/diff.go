@@ -0,0 +1,186 @@
+package sqlmesh
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MetadataDiff is the difference between two collected snapshots.
+type MetadataDiff struct {
+	AddedModels   []string      `json:"added_models,omitempty"`
+	RemovedModels []string      `json:"removed_models,omitempty"`
+	ChangedModels []ModelDiff   `json:"changed_models,omitempty"`
+	AddedEdges    []LineageEdge `json:"added_edges,omitempty"`
+	RemovedEdges  []LineageEdge `json:"removed_edges,omitempty"`
+	ChangedFiles  []FileDiff    `json:"changed_files,omitempty"`
+}
+
+type ModelDiff struct {
+	Name    string        `json:"name"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is a changed model attribute. Columns are reported as
+// `column <name>` with their types, Change tells added and removed columns
+// apart from columns whose type changed, as types may be empty.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+	Change string `json:"change,omitempty"`
+}
+
+// LineageEdge is a dependency of Model on Upstream.
+type LineageEdge struct {
+	Upstream string `json:"upstream"`
+	Model    string `json:"model"`
+}
+
+const (
+	ColumnAdded   = "added"
+	ColumnRemoved = "removed"
+)
+
+const (
+	FileAdded    = "added"
+	FileRemoved  = "removed"
+	FileModified = "modified"
+)
+
+type FileDiff struct {
+	Path   string `json:"path"`
+	Change string `json:"change"`
+}
+
+func (d *MetadataDiff) Empty() bool {
+	return len(d.AddedModels) == 0 && len(d.RemovedModels) == 0 && len(d.ChangedModels) == 0 &&
+		len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0 && len(d.ChangedFiles) == 0
+}
+
+// Diff compares two snapshots. Only file contents collected in both
+// snapshots can be reported as modified.
+func Diff(before *Metadata, after *Metadata) *MetadataDiff {
+	res := &MetadataDiff{}
+
+	for _, modelName := range sortedKeys(after.Models) {
+		beforeModel, ok := before.Models[modelName]
+		if !ok {
+			res.AddedModels = append(res.AddedModels, modelName)
+			continue
+		}
+		if changes := diffModel(beforeModel, after.Models[modelName]); len(changes) > 0 {
+			res.ChangedModels = append(res.ChangedModels, ModelDiff{Name: modelName, Changes: changes})
+		}
+	}
+	for _, modelName := range sortedKeys(before.Models) {
+		if _, ok := after.Models[modelName]; !ok {
+			res.RemovedModels = append(res.RemovedModels, modelName)
+		}
+	}
+
+	beforeEdges := before.Lineage.edges()
+	afterEdges := after.Lineage.edges()
+	for _, edge := range sortedEdges(afterEdges) {
+		if !beforeEdges[edge] {
+			res.AddedEdges = append(res.AddedEdges, edge)
+		}
+	}
+	for _, edge := range sortedEdges(beforeEdges) {
+		if !afterEdges[edge] {
+			res.RemovedEdges = append(res.RemovedEdges, edge)
+		}
+	}
+
+	for _, path := range sortedKeys(after.FileContent) {
+		beforeFile, ok := before.FileContent[path]
+		switch {
+		case !ok:
+			res.ChangedFiles = append(res.ChangedFiles, FileDiff{Path: path, Change: FileAdded})
+		case fileContent(beforeFile) != fileContent(after.FileContent[path]):
+			res.ChangedFiles = append(res.ChangedFiles, FileDiff{Path: path, Change: FileModified})
+		}
+	}
+	for _, path := range sortedKeys(before.FileContent) {
+		if _, ok := after.FileContent[path]; !ok {
+			res.ChangedFiles = append(res.ChangedFiles, FileDiff{Path: path, Change: FileRemoved})
+		}
+	}
+	sort.SliceStable(res.ChangedFiles, func(i, j int) bool {
+		return res.ChangedFiles[i].Path < res.ChangedFiles[j].Path
+	})
+
+	return res
+}
+
+func diffModel(before *Model, after *Model) []FieldChange {
+	var changes []FieldChange
+	compare := func(field string, beforeValue string, afterValue string) {
+		if beforeValue != afterValue {
+			changes = append(changes, FieldChange{Field: field, Old: beforeValue, New: afterValue})
+		}
+	}
+
+	compare("kind", before.Kind(), after.Kind())
+	compare("cron", before.Cron(), after.Cron())
+	compare("owner", before.Owner(), after.Owner())
+	compare("description", before.Description.String(), after.Description.String())
+	compare("path", before.Path, after.Path)
+
+	beforeColumns := columnTypes(before)
+	afterColumns := columnTypes(after)
+	for _, name := range sortedKeys(afterColumns) {
+		field := fmt.Sprintf("column %s", name)
+		beforeType, ok := beforeColumns[name]
+		if !ok {
+			changes = append(changes, FieldChange{Field: field, New: afterColumns[name], Change: ColumnAdded})
+			continue
+		}
+		compare(field, beforeType, afterColumns[name])
+	}
+	for _, name := range sortedKeys(beforeColumns) {
+		if _, ok := afterColumns[name]; !ok {
+			changes = append(changes, FieldChange{Field: fmt.Sprintf("column %s", name), Old: beforeColumns[name], Change: ColumnRemoved})
+		}
+	}
+
+	return changes
+}
+
+func columnTypes(model *Model) map[string]string {
+	res := map[string]string{}
+	for _, column := range model.Columns {
+		res[column.Name] = column.Type.String()
+	}
+	return res
+}
+
+func (g LineageGraph) edges() map[LineageEdge]bool {
+	res := map[LineageEdge]bool{}
+	for modelName, deps := range g {
+		for _, dep := range deps {
+			res[LineageEdge{Upstream: dep, Model: modelName}] = true
+		}
+	}
+	return res
+}
+
+func sortedEdges(edges map[LineageEdge]bool) []LineageEdge {
+	res := make([]LineageEdge, 0, len(edges))
+	for edge := range edges {
+		res = append(res, edge)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Model != res[j].Model {
+			return res[i].Model < res[j].Model
+		}
+		return res[i].Upstream < res[j].Upstream
+	})
+	return res
+}
+
+func fileContent(file *File) string {
+	if file == nil || file.Content == nil {
+		return ""
+	}
+	return *file.Content
+}
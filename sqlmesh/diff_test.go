@@ -0,0 +1,85 @@
+package sqlmesh_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+)
+
+func metadataWith(models ...*sqlmesh.Model) *sqlmesh.Metadata {
+	metadata := &sqlmesh.Metadata{
+		Models:      map[string]*sqlmesh.Model{},
+		Lineage:     sqlmesh.LineageGraph{},
+		FileContent: map[string]*sqlmesh.File{},
+	}
+	for _, model := range models {
+		metadata.Models[model.Name] = model
+	}
+	return metadata
+}
+
+func TestDiffColumns(t *testing.T) {
+	before := metadataWith(&sqlmesh.Model{Name: "example.orders", Columns: []sqlmesh.Column{
+		{Name: "id", Type: "INT"},
+		{Name: "amount"},
+		{Name: "note"},
+	}})
+	after := metadataWith(&sqlmesh.Model{Name: "example.orders", Columns: []sqlmesh.Column{
+		{Name: "id", Type: "BIGINT"},
+		{Name: "amount"},
+		{Name: "status"},
+	}})
+
+	diff := sqlmesh.Diff(before, after)
+	expected := []sqlmesh.ModelDiff{{Name: "example.orders", Changes: []sqlmesh.FieldChange{
+		{Field: "column id", Old: "INT", New: "BIGINT"},
+		{Field: "column status", Change: sqlmesh.ColumnAdded},
+		{Field: "column note", Change: sqlmesh.ColumnRemoved},
+	}}}
+	if !reflect.DeepEqual(diff.ChangedModels, expected) {
+		t.Errorf("expected %+v, got %+v", expected, diff.ChangedModels)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	content := func(s string) *sqlmesh.File { return &sqlmesh.File{Content: &s} }
+
+	before := metadataWith(
+		&sqlmesh.Model{Name: "example.orders", Path: "models/orders.sql"},
+		&sqlmesh.Model{Name: "example.legacy"},
+	)
+	before.Lineage["example.orders"] = []string{"example.legacy"}
+	before.FileContent["models/orders.sql"] = content("SELECT 1")
+	before.FileContent["models/legacy.sql"] = content("SELECT 1")
+
+	after := metadataWith(
+		&sqlmesh.Model{Name: "example.orders", Path: "models/orders.sql", Description: "Orders"},
+		&sqlmesh.Model{Name: "example.raw_orders"},
+	)
+	after.Lineage["example.orders"] = []string{"example.raw_orders"}
+	after.FileContent["models/orders.sql"] = content("SELECT 2")
+	after.FileContent["models/raw_orders.sql"] = content("SELECT 1")
+
+	diff := sqlmesh.Diff(before, after)
+	expected := &sqlmesh.MetadataDiff{
+		AddedModels:   []string{"example.raw_orders"},
+		RemovedModels: []string{"example.legacy"},
+		ChangedModels: []sqlmesh.ModelDiff{{Name: "example.orders", Changes: []sqlmesh.FieldChange{
+			{Field: "description", New: "Orders"},
+		}}},
+		AddedEdges:   []sqlmesh.LineageEdge{{Upstream: "example.raw_orders", Model: "example.orders"}},
+		RemovedEdges: []sqlmesh.LineageEdge{{Upstream: "example.legacy", Model: "example.orders"}},
+		ChangedFiles: []sqlmesh.FileDiff{
+			{Path: "models/legacy.sql", Change: sqlmesh.FileRemoved},
+			{Path: "models/orders.sql", Change: sqlmesh.FileModified},
+			{Path: "models/raw_orders.sql", Change: sqlmesh.FileAdded},
+		},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected %+v, got %+v", expected, diff)
+	}
+	if !sqlmesh.Diff(after, after).Empty() {
+		t.Error("expected no differences between equal snapshots")
+	}
+}
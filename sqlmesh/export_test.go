@@ -0,0 +1,3 @@
+package sqlmesh
+
+var CollectFilesForProcessing = collectFilesForProcessing
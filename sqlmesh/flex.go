@@ -0,0 +1,89 @@
+package sqlmesh
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FlexString decodes values which changed their JSON type between SQLMesh
+// versions: strings, numbers, booleans and objects with a `name` (e.g. model
+// kind) are all accepted, null leaves it empty.
+type FlexString string
+
+func (f *FlexString) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		*f = ""
+		return nil
+	}
+
+	switch data[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*f = FlexString(s)
+	case '{':
+		named := struct {
+			Name FlexString `json:"name"`
+		}{}
+		if err := json.Unmarshal(data, &named); err != nil {
+			return err
+		}
+		*f = named.Name
+	case '[':
+		var items FlexStrings
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		*f = FlexString(strings.Join(items.Strings(), ","))
+	default:
+		*f = FlexString(data)
+	}
+	return nil
+}
+
+func (f FlexString) String() string {
+	return string(f)
+}
+
+// FlexStrings decodes either a list of FlexString values or a single value,
+// e.g. `grain` which may be a column name or a list of them.
+type FlexStrings []FlexString
+
+func (f *FlexStrings) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		*f = nil
+		return nil
+	}
+
+	if data[0] != '[' {
+		var single FlexString
+		if err := json.Unmarshal(data, &single); err != nil {
+			return err
+		}
+		if single != "" {
+			*f = FlexStrings{single}
+		}
+		return nil
+	}
+
+	var items []FlexString
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("decoding list: %w", err)
+	}
+	*f = items
+	return nil
+}
+
+func (f FlexStrings) Strings() []string {
+	var res []string
+	for _, s := range f {
+		res = append(res, string(s))
+	}
+	return res
+}
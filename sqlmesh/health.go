@@ -1,25 +1,33 @@
 package sqlmesh
 
 import (
-	"github.com/sirupsen/logrus"
+	"errors"
 	"net/url"
-	"os"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
-func WaitForSQLMeshToStart(url url.URL) {
+var ErrSQLMeshNotStarted = errors.New("SQLMesh did not start in time")
+
+func WaitForSQLMeshToStart(url url.URL) error {
 
-	logrus.Info("Waiting for sqlmesh to start")
+	log := logrus.WithField("url", url.String())
+	log.Info("Waiting for sqlmesh to start")
 	api := NewAPIClient(url)
 	t := time.Now()
-	for t.Add(30 * time.Second).After(time.Now()) {
+	for attempt := 1; t.Add(30 * time.Second).After(time.Now()); attempt++ {
 		_, err := api.Health()
 		if err == nil {
-			return
+			log.WithFields(logrus.Fields{
+				"attempt":     attempt,
+				"duration_ms": time.Since(t).Milliseconds(),
+			}).Info("SQLMesh started")
+			return nil
 		}
-		logrus.WithError(err).Error("Failed to get health of SQLMesh api")
+		log.WithError(err).WithField("attempt", attempt).Error("Failed to get health of SQLMesh api")
 		time.Sleep(1 * time.Second)
 	}
-	logrus.Error("SQLMesh did not start in time")
-	os.Exit(0)
+	log.WithField("duration_ms", time.Since(t).Milliseconds()).Error(ErrSQLMeshNotStarted)
+	return ErrSQLMeshNotStarted
 }
@@ -0,0 +1,143 @@
+package sqlmesh
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	ingestsqlmeshv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/sqlmesh/v1"
+)
+
+// ImpactReport describes which models are affected by changed files, e.g.
+// of a pull request.
+type ImpactReport struct {
+	ChangedSince     string            `json:"changed_since"`
+	ChangedFiles     []string          `json:"changed_files"`
+	UnmappedFiles    []string          `json:"unmapped_files,omitempty"`
+	ChangedModels    []string          `json:"changed_models"`
+	DownstreamModels []DownstreamModel `json:"downstream_models,omitempty"`
+}
+
+// DownstreamModel is a model which was not changed itself but depends on
+// changed models.
+type DownstreamModel struct {
+	Name            string   `json:"name"`
+	ChangedUpstream []string `json:"changed_upstream"`
+}
+
+// modelSet is a model selector accepting models of the set.
+type modelSet map[string]bool
+
+func (s modelSet) Match(modelName string) (bool, error) {
+	return s[modelName], nil
+}
+
+// changedModels returns names of models defined in the changed files, paths
+// of the /api/models entries are relative to the project like the files.
+func changedModels(models json.RawMessage, changedFiles []string) (modelSet, error) {
+	decoded, err := DecodeModels(models)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]bool{}
+	for _, file := range changedFiles {
+		files[file] = true
+	}
+	res := modelSet{}
+	for _, model := range decoded {
+		if model.Name != "" && files[model.Path] {
+			res[model.Name] = true
+		}
+	}
+	return res, nil
+}
+
+// BuildImpactReport maps changed files to collected models. Collected models
+// which are not defined in changed files are reported as downstream with
+// the changed models they depend on.
+func BuildImpactReport(req *ingestsqlmeshv1.IngestMetadataRequest, changedSince string, changedFiles []string) *ImpactReport {
+	report := &ImpactReport{
+		ChangedSince:  changedSince,
+		ChangedFiles:  changedFiles,
+		ChangedModels: []string{},
+	}
+
+	models, err := DecodeModels(req.Models)
+	if err != nil {
+		return report
+	}
+	modelPaths := map[string]bool{}
+	for _, model := range models {
+		modelPaths[model.Path] = true
+	}
+	for _, file := range changedFiles {
+		if !modelPaths[file] {
+			report.UnmappedFiles = append(report.UnmappedFiles, file)
+		}
+	}
+
+	changed, err := changedModels(req.Models, changedFiles)
+	if err != nil {
+		return report
+	}
+	report.ChangedModels = sortedKeys(changed)
+
+	metadata, _ := DecodeMetadata(req)
+	for _, modelName := range sortedKeys(metadata.Models) {
+		if changed[modelName] {
+			continue
+		}
+		var changedUpstream []string
+		for upstream := range metadata.Lineage.Reachable([]string{modelName}, 0) {
+			if changed[upstream] {
+				changedUpstream = append(changedUpstream, upstream)
+			}
+		}
+		sort.Strings(changedUpstream)
+		report.DownstreamModels = append(report.DownstreamModels, DownstreamModel{
+			Name:            modelName,
+			ChangedUpstream: changedUpstream,
+		})
+	}
+
+	return report
+}
+
+// WriteMarkdown renders the report for pull request comments.
+func (r *ImpactReport) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### SQLMesh impact of changes since `%s`\n\n", r.ChangedSince)
+	fmt.Fprintf(&b, "%d changed files, %d changed models, %d downstream models.\n\n", len(r.ChangedFiles), len(r.ChangedModels), len(r.DownstreamModels))
+
+	if len(r.ChangedModels) > 0 || len(r.DownstreamModels) > 0 {
+		b.WriteString("| Model | Impact |\n")
+		b.WriteString("|-------|--------|\n")
+		for _, modelName := range r.ChangedModels {
+			fmt.Fprintf(&b, "| `%s` | changed |\n", modelName)
+		}
+		for _, model := range r.DownstreamModels {
+			fmt.Fprintf(&b, "| `%s` | downstream of %s |\n", model.Name, markdownCodeList(model.ChangedUpstream))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.UnmappedFiles) > 0 {
+		b.WriteString("Changed files not defining a collected model (e.g. macros or configuration) may affect further models:\n\n")
+		for _, file := range r.UnmappedFiles {
+			fmt.Fprintf(&b, "- `%s`\n", file)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownCodeList(values []string) string {
+	var quoted []string
+	for _, value := range values {
+		quoted = append(quoted, "`"+value+"`")
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,65 @@
+package sqlmesh_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+)
+
+func TestCollectMetadataChangedDownstream(t *testing.T) {
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			testCollectMetadataChangedDownstream(t, concurrency)
+		})
+	}
+}
+
+func testCollectMetadataChangedDownstream(t *testing.T, concurrency int) {
+	_, baseUrl := startFixtureServer(t, "sqlmesh-0.130")
+	changedFiles := []string{"models/orders.sql", "macros/util.py"}
+
+	res, err := sqlmesh.CollectMetadata(baseUrl, sqlmesh.NewExcludeEverythingGlobFilter(),
+		sqlmesh.WithChangedFiles(changedFiles, true), sqlmesh.WithConcurrency(concurrency))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("unexpected collection errors: %v", res.Errors)
+	}
+	expected := []string{"example.customer_revenue", "example.orders"}
+	if got := keys(res.ModelDetails); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected details of %v, got %v", expected, got)
+	}
+	if got := keys(res.ModelLineage); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected lineage of %v, got %v", expected, got)
+	}
+
+	report := sqlmesh.BuildImpactReport(res, "origin/main", changedFiles)
+	expectedReport := &sqlmesh.ImpactReport{
+		ChangedSince:  "origin/main",
+		ChangedFiles:  changedFiles,
+		UnmappedFiles: []string{"macros/util.py"},
+		ChangedModels: []string{"example.orders"},
+		DownstreamModels: []sqlmesh.DownstreamModel{
+			{Name: "example.customer_revenue", ChangedUpstream: []string{"example.orders"}},
+		},
+	}
+	if !reflect.DeepEqual(report, expectedReport) {
+		t.Errorf("expected %+v, got %+v", expectedReport, report)
+	}
+}
+
+func TestCollectMetadataChangedWithoutDownstream(t *testing.T) {
+	_, baseUrl := startFixtureServer(t, "sqlmesh-0.96")
+
+	res, err := sqlmesh.CollectMetadata(baseUrl, sqlmesh.NewExcludeEverythingGlobFilter(),
+		sqlmesh.WithChangedFiles([]string{"models/orders.sql"}, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keys(res.ModelDetails); !reflect.DeepEqual(got, []string{"example.orders"}) {
+		t.Errorf("expected details of example.orders, got %v", got)
+	}
+}
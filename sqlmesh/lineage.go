@@ -0,0 +1,178 @@
+package sqlmesh
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	LineageFormatDot     = "dot"
+	LineageFormatMermaid = "mermaid"
+	LineageFormatJson    = "json"
+)
+
+// Nodes returns all models of the graph, including dependencies which have
+// no lineage entry of their own.
+func (g LineageGraph) Nodes() []string {
+	nodes := map[string]bool{}
+	for modelName, deps := range g {
+		nodes[modelName] = true
+		for _, dep := range deps {
+			nodes[dep] = true
+		}
+	}
+	return sortedKeys(nodes)
+}
+
+// Downstream returns the reversed graph, mapping a model to the models which
+// directly depend on it.
+func (g LineageGraph) Downstream() LineageGraph {
+	res := LineageGraph{}
+	for _, node := range g.Nodes() {
+		res[node] = []string{}
+	}
+	for modelName, deps := range g {
+		for _, dep := range deps {
+			res[dep] = append(res[dep], modelName)
+		}
+	}
+	for _, deps := range res {
+		sort.Strings(deps)
+	}
+	return res
+}
+
+// Reachable returns models reachable from the roots within depth steps,
+// roots included. Non-positive depth is unlimited.
+func (g LineageGraph) Reachable(roots []string, depth int) map[string]bool {
+	visited := map[string]bool{}
+	frontier := roots
+	for _, root := range roots {
+		visited[root] = true
+	}
+	for step := 0; len(frontier) > 0 && (depth <= 0 || step < depth); step++ {
+		var next []string
+		for _, node := range frontier {
+			for _, dep := range g[node] {
+				if !visited[dep] {
+					visited[dep] = true
+					next = append(next, dep)
+				}
+			}
+		}
+		frontier = next
+	}
+	return visited
+}
+
+// Focus returns the subgraph of the model with its upstream and/or
+// downstream models within depth steps.
+func (g LineageGraph) Focus(model string, depth int, upstream bool, downstream bool) LineageGraph {
+	keep := map[string]bool{model: true}
+	if upstream {
+		for node := range g.Reachable([]string{model}, depth) {
+			keep[node] = true
+		}
+	}
+	if downstream {
+		for node := range g.Downstream().Reachable([]string{model}, depth) {
+			keep[node] = true
+		}
+	}
+	return g.Subgraph(keep)
+}
+
+// Subgraph keeps only the given models and edges between them.
+func (g LineageGraph) Subgraph(keep map[string]bool) LineageGraph {
+	res := LineageGraph{}
+	for _, node := range g.Nodes() {
+		if !keep[node] {
+			continue
+		}
+		deps := []string{}
+		for _, dep := range g[node] {
+			if keep[dep] {
+				deps = append(deps, dep)
+			}
+		}
+		res[node] = deps
+	}
+	return res
+}
+
+// RenderLineage writes the graph in one of the LineageFormat* formats, the
+// highlighted model is emphasized where the format allows it.
+func RenderLineage(w io.Writer, g LineageGraph, format string, highlight string) error {
+	switch format {
+	case LineageFormatDot:
+		return renderDot(w, g, highlight)
+	case LineageFormatMermaid:
+		return renderMermaid(w, g, highlight)
+	case LineageFormatJson:
+		adjacency := LineageGraph{}
+		for _, node := range g.Nodes() {
+			adjacency[node] = []string{}
+		}
+		adjacency.Merge(g)
+		asJson, err := json.MarshalIndent(adjacency, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(asJson))
+		return err
+	default:
+		return fmt.Errorf("unknown lineage format %s, expected dot, mermaid or json", format)
+	}
+}
+
+func renderDot(w io.Writer, g LineageGraph, highlight string) error {
+	var b strings.Builder
+	b.WriteString("digraph lineage {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, node := range g.Nodes() {
+		if node == highlight {
+			fmt.Fprintf(&b, "  %s [style=bold];\n", dotQuote(node))
+		} else {
+			fmt.Fprintf(&b, "  %s;\n", dotQuote(node))
+		}
+	}
+	for _, modelName := range sortedKeys(g) {
+		for _, dep := range g[modelName] {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(dep), dotQuote(modelName))
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// renderMermaid uses generated node ids, model names may contain characters
+// which are not valid in Mermaid ids.
+func renderMermaid(w io.Writer, g LineageGraph, highlight string) error {
+	ids := map[string]string{}
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, node := range g.Nodes() {
+		ids[node] = fmt.Sprintf("n%d", i)
+		label := strings.ReplaceAll(node, `"`, "#quot;")
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[node], label)
+	}
+	for _, modelName := range sortedKeys(g) {
+		for _, dep := range g[modelName] {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[dep], ids[modelName])
+		}
+	}
+	if id, ok := ids[highlight]; ok {
+		fmt.Fprintf(&b, "  style %s stroke-width:3px\n", id)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,148 @@
+package sqlmesh_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+)
+
+// chain is a <- b <- c <- d with e also depending on b.
+var chain = sqlmesh.LineageGraph{
+	"b": {"a"},
+	"c": {"b"},
+	"d": {"c"},
+	"e": {"b"},
+}
+
+func TestLineageReachable(t *testing.T) {
+	tests := []struct {
+		name     string
+		graph    sqlmesh.LineageGraph
+		roots    []string
+		depth    int
+		expected []string
+	}{
+		{"depth 1", chain, []string{"d"}, 1, []string{"c", "d"}},
+		{"depth 2", chain, []string{"d"}, 2, []string{"b", "c", "d"}},
+		{"unlimited", chain, []string{"d"}, 0, []string{"a", "b", "c", "d"}},
+		{"depth past the end", chain, []string{"d"}, 10, []string{"a", "b", "c", "d"}},
+		{"several roots", chain, []string{"c", "e"}, 1, []string{"b", "c", "e"}},
+		{"unknown root", chain, []string{"x"}, 0, []string{"x"}},
+		{"cycle", sqlmesh.LineageGraph{"x": {"y"}, "y": {"x"}}, []string{"x"}, 0, []string{"x", "y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keys(tt.graph.Reachable(tt.roots, tt.depth)); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLineageFocus(t *testing.T) {
+	tests := []struct {
+		name       string
+		graph      sqlmesh.LineageGraph
+		model      string
+		depth      int
+		upstream   bool
+		downstream bool
+		expected   sqlmesh.LineageGraph
+	}{
+		{
+			name: "upstream", graph: chain, model: "c", depth: 0, upstream: true,
+			expected: sqlmesh.LineageGraph{"a": {}, "b": {"a"}, "c": {"b"}},
+		},
+		{
+			name: "upstream depth 1", graph: chain, model: "c", depth: 1, upstream: true,
+			expected: sqlmesh.LineageGraph{"b": {}, "c": {"b"}},
+		},
+		{
+			name: "downstream", graph: chain, model: "b", depth: 0, downstream: true,
+			expected: sqlmesh.LineageGraph{"b": {}, "c": {"b"}, "d": {"c"}, "e": {"b"}},
+		},
+		{
+			name: "downstream depth 1", graph: chain, model: "b", depth: 1, downstream: true,
+			expected: sqlmesh.LineageGraph{"b": {}, "c": {"b"}, "e": {"b"}},
+		},
+		{
+			name: "both depth 1", graph: chain, model: "c", depth: 1, upstream: true, downstream: true,
+			expected: sqlmesh.LineageGraph{"b": {}, "c": {"b"}, "d": {"c"}},
+		},
+		{
+			name: "neither", graph: chain, model: "c", depth: 0,
+			expected: sqlmesh.LineageGraph{"c": {}},
+		},
+		{
+			name:  "cycle",
+			graph: sqlmesh.LineageGraph{"x": {"y"}, "y": {"x"}, "z": {"y"}},
+			model: "x", depth: 0, downstream: true,
+			expected: sqlmesh.LineageGraph{"x": {"y"}, "y": {"x"}, "z": {"y"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.graph.Focus(tt.model, tt.depth, tt.upstream, tt.downstream)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRenderLineage(t *testing.T) {
+	graph := sqlmesh.LineageGraph{
+		"example.orders": {"example.raw_orders"},
+		`odd"name`:       {"example.orders"},
+	}
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{sqlmesh.LineageFormatDot, `digraph lineage {
+  rankdir=LR;
+  node [shape=box];
+  "example.orders" [style=bold];
+  "example.raw_orders";
+  "odd\"name";
+  "example.raw_orders" -> "example.orders";
+  "example.orders" -> "odd\"name";
+}
+`},
+		{sqlmesh.LineageFormatMermaid, `flowchart LR
+  n0["example.orders"]
+  n1["example.raw_orders"]
+  n2["odd#quot;name"]
+  n1 --> n0
+  n0 --> n2
+  style n0 stroke-width:3px
+`},
+		{sqlmesh.LineageFormatJson, `{
+  "example.orders": [
+    "example.raw_orders"
+  ],
+  "example.raw_orders": [],
+  "odd\"name": [
+    "example.orders"
+  ]
+}
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := sqlmesh.RenderLineage(&out, graph, tt.format, "example.orders"); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tt.expected, out.String())
+			}
+		})
+	}
+
+	if err := sqlmesh.RenderLineage(&bytes.Buffer{}, graph, "svg", ""); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
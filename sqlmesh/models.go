@@ -0,0 +1,385 @@
+package sqlmesh
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	ingestsqlmeshv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/sqlmesh/v1"
+	"github.com/samber/lo"
+)
+
+// Typed views of SQLMesh UI payloads. Decoding is lenient: unknown fields are
+// ignored and values whose type differs across versions use FlexString. The
+// raw payloads are still what gets uploaded to SYNQ.
+
+type Model struct {
+	Name           string        `json:"name"`
+	Fqn            string        `json:"fqn"`
+	Path           string        `json:"path"`
+	FullPath       string        `json:"full_path"`
+	Dialect        string        `json:"dialect"`
+	Type           FlexString    `json:"type"`
+	Columns        []Column      `json:"columns"`
+	Details        *ModelDetails `json:"details"`
+	Description    FlexString    `json:"description"`
+	Sql            FlexString    `json:"sql"`
+	Definition     FlexString    `json:"definition"`
+	DefaultCatalog FlexString    `json:"default_catalog"`
+	Hash           FlexString    `json:"hash"`
+}
+
+type Column struct {
+	Name        string     `json:"name"`
+	Type        FlexString `json:"type"`
+	Description FlexString `json:"description"`
+}
+
+type ModelDetails struct {
+	Owner         FlexString  `json:"owner"`
+	Kind          FlexString  `json:"kind"`
+	BatchSize     FlexString  `json:"batch_size"`
+	Cron          FlexString  `json:"cron"`
+	Stamp         FlexString  `json:"stamp"`
+	Start         FlexString  `json:"start"`
+	Retention     FlexString  `json:"retention"`
+	TableFormat   FlexString  `json:"table_format"`
+	StorageFormat FlexString  `json:"storage_format"`
+	TimeColumn    FlexString  `json:"time_column"`
+	Tags          FlexStrings `json:"tags"`
+	References    FlexStrings `json:"references"`
+	PartitionedBy FlexStrings `json:"partitioned_by"`
+	ClusteredBy   FlexStrings `json:"clustered_by"`
+	Lookback      FlexString  `json:"lookback"`
+	CronPrev      FlexString  `json:"cron_prev"`
+	CronNext      FlexString  `json:"cron_next"`
+	IntervalUnit  FlexString  `json:"interval_unit"`
+	Annotated     FlexString  `json:"annotated"`
+	Grain         FlexStrings `json:"grain"`
+	Audits        FlexStrings `json:"audits"`
+}
+
+// Kind returns model kind or empty string when details are missing, the same
+// pattern is used by the other accessors.
+func (m *Model) Kind() string {
+	if m.Details == nil {
+		return ""
+	}
+	return m.Details.Kind.String()
+}
+
+func (m *Model) Cron() string {
+	if m.Details == nil {
+		return ""
+	}
+	return m.Details.Cron.String()
+}
+
+func (m *Model) Owner() string {
+	if m.Details == nil {
+		return ""
+	}
+	return m.Details.Owner.String()
+}
+
+func (m *Model) Tags() []string {
+	if m.Details == nil {
+		return nil
+	}
+	return m.Details.Tags.Strings()
+}
+
+// LineageGraph maps a model to the models it directly depends on.
+type LineageGraph map[string][]string
+
+type Environment struct {
+	Name      string            `json:"name"`
+	StartAt   FlexString        `json:"start_at"`
+	EndAt     FlexString        `json:"end_at"`
+	PlanId    FlexString        `json:"plan_id"`
+	Snapshots []json.RawMessage `json:"snapshots"`
+}
+
+type Environments struct {
+	Environments             map[string]*Environment `json:"environments"`
+	PinnedEnvironments       []string                `json:"pinned_environments"`
+	DefaultTargetEnvironment string                  `json:"default_target_environment"`
+}
+
+// modelsPayload is the /api/models payload split into model entries, a list
+// of models optionally wrapped in an object with a `models` list.
+type modelsPayload struct {
+	Entries []json.RawMessage
+	// envelope holds the other keys of the wrapping object, nil for a list
+	envelope map[string]json.RawMessage
+}
+
+func decodeModelsPayload(raw json.RawMessage) (*modelsPayload, error) {
+	res := &modelsPayload{}
+	err := json.Unmarshal(raw, &res.Entries)
+	if err == nil {
+		return res, nil
+	}
+	envelope := map[string]json.RawMessage{}
+	if json.Unmarshal(raw, &envelope) != nil || envelope["models"] == nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(envelope["models"], &res.Entries); err != nil {
+		return nil, err
+	}
+	res.envelope = envelope
+	return res, nil
+}
+
+// withEntries encodes the entries in the shape of the decoded payload.
+func (p *modelsPayload) withEntries(entries []json.RawMessage) (json.RawMessage, error) {
+	if entries == nil {
+		entries = []json.RawMessage{}
+	}
+	if p.envelope == nil {
+		return json.Marshal(entries)
+	}
+	envelope := map[string]json.RawMessage{}
+	for key, value := range p.envelope {
+		envelope[key] = value
+	}
+	encoded, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	envelope["models"] = encoded
+	return json.Marshal(envelope)
+}
+
+// DecodeModels decodes the /api/models payload, a list of models optionally
+// wrapped in an object.
+func DecodeModels(raw json.RawMessage) ([]*Model, error) {
+	payload, err := decodeModelsPayload(raw)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]*Model, 0, len(payload.Entries))
+	for _, entry := range payload.Entries {
+		model, err := DecodeModel(entry)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, model)
+	}
+	return models, nil
+}
+
+func DecodeModel(raw json.RawMessage) (*Model, error) {
+	model := &Model{}
+	if err := json.Unmarshal(raw, model); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+// DecodeLineage decodes the /api/lineage/<model> payload as returned, models
+// are identified by fqn, see ModelIndex. Dependencies are either a list or an
+// object with a `models` list.
+func DecodeLineage(raw json.RawMessage) (LineageGraph, error) {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+
+	graph := LineageGraph{}
+	for modelName, entry := range entries {
+		var deps FlexStrings
+		if err := json.Unmarshal(entry, &deps); err != nil {
+			nested := struct {
+				Models FlexStrings `json:"models"`
+			}{}
+			if json.Unmarshal(entry, &nested) != nil {
+				return nil, fmt.Errorf("lineage of %s: %w", modelName, err)
+			}
+			deps = nested.Models
+		}
+		graph[modelName] = deps.Strings()
+	}
+	return graph, nil
+}
+
+// DecodeEnvironments decodes the /api/environments payload, a bare map of
+// environments is accepted too.
+func DecodeEnvironments(raw json.RawMessage) (*Environments, error) {
+	res := &Environments{}
+	if err := json.Unmarshal(raw, res); err != nil {
+		return nil, err
+	}
+	if res.Environments == nil {
+		bare := map[string]*Environment{}
+		if json.Unmarshal(raw, &bare) == nil {
+			res.Environments = bare
+		}
+	}
+	for name, env := range res.Environments {
+		if env != nil && env.Name == "" {
+			env.Name = name
+		}
+	}
+	return res, nil
+}
+
+// Metadata is the typed view of a collected IngestMetadataRequest.
+type Metadata struct {
+	Models       map[string]*Model
+	Lineage      LineageGraph
+	Environments *Environments
+	Files        *Directory
+	FileContent  map[string]*File
+}
+
+// DecodeMetadata decodes all payloads of the request. Decoding continues
+// past broken payloads, their errors are returned alongside.
+func DecodeMetadata(req *ingestsqlmeshv1.IngestMetadataRequest) (*Metadata, []error) {
+	var errs []error
+	res := &Metadata{
+		Models:      map[string]*Model{},
+		Lineage:     LineageGraph{},
+		FileContent: map[string]*File{},
+	}
+
+	if len(req.Models) > 0 {
+		models, err := DecodeModels(req.Models)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("models: %w", err))
+		}
+		for _, model := range models {
+			if model.Name != "" {
+				res.Models[model.Name] = model
+			}
+		}
+	}
+
+	// details are richer than the list entries
+	for modelName, raw := range req.ModelDetails {
+		if len(raw) == 0 {
+			continue
+		}
+		model, err := DecodeModel(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("model details of %s: %w", modelName, err))
+			continue
+		}
+		if model.Name == "" {
+			model.Name = modelName
+		}
+		res.Models[modelName] = model
+	}
+
+	index := NewModelIndex(lo.Values(res.Models))
+	for _, modelName := range sortedKeys(req.ModelLineage) {
+		raw := req.ModelLineage[modelName]
+		if len(raw) == 0 {
+			continue
+		}
+		graph, err := DecodeLineage(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("lineage of %s: %w", modelName, err))
+			continue
+		}
+		res.Lineage.Merge(index.Normalize(graph))
+	}
+
+	if len(req.Environments) > 0 {
+		environments, err := DecodeEnvironments(req.Environments)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("environments: %w", err))
+		}
+		res.Environments = environments
+	}
+
+	if len(req.Files) > 0 {
+		dir := &Directory{}
+		if err := json.Unmarshal(req.Files, dir); err != nil {
+			errs = append(errs, fmt.Errorf("files: %w", err))
+		} else {
+			res.Files = dir
+		}
+	}
+
+	for path, raw := range req.FileContent {
+		file := &File{}
+		if err := json.Unmarshal(raw, file); err != nil {
+			errs = append(errs, fmt.Errorf("file content of %s: %w", path, err))
+			continue
+		}
+		res.FileContent[path] = file
+	}
+
+	return res, errs
+}
+
+// ModelIndex maps fully qualified names of models, e.g.
+// `"db"."example"."orders"`, to model names. SQLMesh UI keys lineage by fqn
+// while models, details and changed files are keyed by name.
+type ModelIndex map[string]string
+
+func NewModelIndex(models []*Model) ModelIndex {
+	index := ModelIndex{}
+	for _, model := range models {
+		if model != nil && model.Name != "" && model.Fqn != "" {
+			index[model.Fqn] = model.Name
+		}
+	}
+	return index
+}
+
+// Name returns the model name of the fqn, names and fqns of unknown models
+// are returned unchanged.
+func (idx ModelIndex) Name(id string) string {
+	if name, ok := idx[id]; ok {
+		return name
+	}
+	return id
+}
+
+// Normalize returns the graph with models and dependencies identified by
+// model names.
+func (idx ModelIndex) Normalize(g LineageGraph) LineageGraph {
+	res := LineageGraph{}
+	for id, deps := range g {
+		names := make([]string, 0, len(deps))
+		for _, dep := range deps {
+			names = append(names, idx.Name(dep))
+		}
+		res.Merge(LineageGraph{idx.Name(id): names})
+	}
+	return res
+}
+
+// Merge adds edges of the other graph, keeping dependencies unique and
+// sorted.
+func (g LineageGraph) Merge(other LineageGraph) {
+	for modelName, deps := range other {
+		existing := map[string]bool{}
+		for _, dep := range g[modelName] {
+			existing[dep] = true
+		}
+		merged := g[modelName]
+		if merged == nil {
+			merged = []string{}
+		}
+		for _, dep := range deps {
+			if !existing[dep] {
+				existing[dep] = true
+				merged = append(merged, dep)
+			}
+		}
+		sort.Strings(merged)
+		g[modelName] = merged
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,55 @@
+package sqlmesh_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+)
+
+func TestDecodeMetadataNormalizesLineage(t *testing.T) {
+	_, baseUrl := startFixtureServer(t, "sqlmesh-0.130")
+	req, err := sqlmesh.CollectMetadata(baseUrl, sqlmesh.NewExcludeEverythingGlobFilter())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := sqlmesh.DecodeLineage(req.ModelLineage["example.customer_revenue"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw[`"db"."example"."customer_revenue"`]; !ok {
+		t.Fatalf("expected lineage keyed by fqn, got %v", raw)
+	}
+
+	metadata, errs := sqlmesh.DecodeMetadata(req)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	expected := sqlmesh.LineageGraph{
+		"example.country_codes":    {},
+		"example.customer_revenue": {"example.orders"},
+		"example.orders":           {"example.raw_orders"},
+		"example.raw_orders":       {},
+	}
+	if !reflect.DeepEqual(metadata.Lineage, expected) {
+		t.Errorf("expected lineage %v, got %v", expected, metadata.Lineage)
+	}
+
+	for _, finding := range sqlmesh.Validate(req, nil) {
+		if finding.Rule == sqlmesh.RuleUnknownLineage {
+			t.Errorf("unexpected finding %+v", finding)
+		}
+	}
+}
+
+func TestModelIndexKeepsUnknownModels(t *testing.T) {
+	index := sqlmesh.NewModelIndex([]*sqlmesh.Model{{Name: "example.orders", Fqn: `"db"."example"."orders"`}})
+	graph := index.Normalize(sqlmesh.LineageGraph{
+		`"db"."example"."orders"`: {`"db"."example"."raw_orders"`, "example.orders_seed"},
+	})
+	expected := sqlmesh.LineageGraph{"example.orders": {`"db"."example"."raw_orders"`, "example.orders_seed"}}
+	if !reflect.DeepEqual(graph, expected) {
+		t.Errorf("expected %v, got %v", expected, graph)
+	}
+}
@@ -0,0 +1,34 @@
+package sqlmesh
+
+import "sync"
+
+// workerPool runs submitted functions with bounded parallelism.
+type workerPool struct {
+	wg  sync.WaitGroup
+	sem chan struct{}
+}
+
+func newWorkerPool(size int) *workerPool {
+	if size < 1 {
+		size = 1
+	}
+	return &workerPool{
+		sem: make(chan struct{}, size),
+	}
+}
+
+func (p *workerPool) Go(f func()) {
+	p.wg.Add(1)
+	p.sem <- struct{}{}
+	go func() {
+		defer func() {
+			<-p.sem
+			p.wg.Done()
+		}()
+		f()
+	}()
+}
+
+func (p *workerPool) Wait() {
+	p.wg.Wait()
+}
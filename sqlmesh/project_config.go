@@ -0,0 +1,181 @@
+package sqlmesh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfig is a summary of the SQLMesh project configuration. Only
+// names and types are kept, connection settings may contain secrets and are
+// never read into it.
+type ProjectConfig struct {
+	Path           string            `json:"path"`
+	Format         string            `json:"format"`
+	Project        string            `json:"project,omitempty"`
+	DefaultGateway string            `json:"default_gateway,omitempty"`
+	Gateways       []GatewayConfig   `json:"gateways,omitempty"`
+	ModelDefaults  map[string]string `json:"model_defaults,omitempty"`
+	Ui             map[string]string `json:"ui,omitempty"`
+}
+
+type GatewayConfig struct {
+	Name           string `json:"name"`
+	ConnectionType string `json:"connection_type,omitempty"`
+}
+
+func (c *ProjectConfig) Dialect() string {
+	if c == nil {
+		return ""
+	}
+	return c.ModelDefaults["dialect"]
+}
+
+func (c *ProjectConfig) GatewayNames() []string {
+	if c == nil {
+		return nil
+	}
+	var names []string
+	for _, gateway := range c.Gateways {
+		names = append(names, gateway.Name)
+	}
+	return names
+}
+
+func (c *ProjectConfig) HasGateway(name string) bool {
+	for _, gateway := range c.GatewayNames() {
+		if gateway == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadProjectConfig discovers the configuration of SQLMesh project in the
+// directory. YAML configs are parsed, for `config.py` only well-known
+// keyword arguments are detected. Returns nil when no config is found.
+func ReadProjectConfig(projectDir string) (*ProjectConfig, error) {
+	for _, name := range []string{"config.yaml", "config.yml"} {
+		path := filepath.Join(projectDir, name)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		return parseYamlProjectConfig(path, content)
+	}
+
+	path := filepath.Join(projectDir, "config.py")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil
+	}
+	return parsePythonProjectConfig(path, content), nil
+}
+
+// jinjaExpressionRe matches `{{ env_var('X') }}` like expressions which are
+// rendered by SQLMesh before the YAML is parsed.
+var jinjaExpressionRe = regexp.MustCompile(`\{\{.*?\}\}`)
+
+func parseYamlProjectConfig(path string, content []byte) (*ProjectConfig, error) {
+	content = jinjaExpressionRe.ReplaceAll(content, []byte("jinja"))
+	raw := struct {
+		Project        string                 `yaml:"project"`
+		DefaultGateway string                 `yaml:"default_gateway"`
+		Gateways       map[string]yaml.Node   `yaml:"gateways"`
+		ModelDefaults  map[string]interface{} `yaml:"model_defaults"`
+		Ui             map[string]interface{} `yaml:"ui"`
+	}{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	config := &ProjectConfig{
+		Path:           path,
+		Format:         "yaml",
+		Project:        raw.Project,
+		DefaultGateway: raw.DefaultGateway,
+		ModelDefaults:  stringValues(raw.ModelDefaults),
+		Ui:             stringValues(raw.Ui),
+	}
+
+	for name, node := range raw.Gateways {
+		gateway := struct {
+			Connection struct {
+				Type string `yaml:"type"`
+			} `yaml:"connection"`
+		}{}
+		_ = node.Decode(&gateway)
+		config.Gateways = append(config.Gateways, GatewayConfig{
+			Name:           name,
+			ConnectionType: gateway.Connection.Type,
+		})
+	}
+	sort.Slice(config.Gateways, func(i, j int) bool {
+		return config.Gateways[i].Name < config.Gateways[j].Name
+	})
+
+	// SQLMesh uses the first gateway when no default is configured.
+	if config.DefaultGateway == "" && len(raw.Gateways) > 0 {
+		var root yaml.Node
+		if yaml.Unmarshal(content, &root) == nil {
+			config.DefaultGateway = firstGatewayName(&root)
+		}
+	}
+
+	return config, nil
+}
+
+func firstGatewayName(root *yaml.Node) string {
+	if len(root.Content) == 0 {
+		return ""
+	}
+	doc := root.Content[0]
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value == "gateways" && len(doc.Content[i+1].Content) > 0 {
+			return doc.Content[i+1].Content[0].Value
+		}
+	}
+	return ""
+}
+
+var (
+	pythonDefaultGatewayRe = regexp.MustCompile(`default_gateway\s*=\s*["']([^"']+)["']`)
+	pythonDialectRe        = regexp.MustCompile(`dialect\s*=\s*["']([^"']+)["']`)
+	pythonProjectRe        = regexp.MustCompile(`\bproject\s*=\s*["']([^"']+)["']`)
+)
+
+func parsePythonProjectConfig(path string, content []byte) *ProjectConfig {
+	config := &ProjectConfig{
+		Path:   path,
+		Format: "python",
+	}
+	if m := pythonDefaultGatewayRe.FindSubmatch(content); m != nil {
+		config.DefaultGateway = string(m[1])
+	}
+	if m := pythonProjectRe.FindSubmatch(content); m != nil {
+		config.Project = string(m[1])
+	}
+	if m := pythonDialectRe.FindSubmatch(content); m != nil {
+		config.ModelDefaults = map[string]string{"dialect": string(m[1])}
+	}
+	return config
+}
+
+func stringValues(values map[string]interface{}) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	res := make(map[string]string)
+	for k, v := range values {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			continue
+		}
+		res[k] = fmt.Sprint(v)
+	}
+	return res
+}
@@ -0,0 +1,92 @@
+// Package sqlmeshtest provides a fake SQLMesh UI server replaying recorded
+// fixtures, so code built on top of the `sqlmesh` package can be exercised
+// offline and against payloads of multiple SQLMesh versions.
+package sqlmeshtest
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed fixtures/*.json
+var fixturesFS embed.FS
+
+// Response is a single recorded reply of SQLMesh UI. JSON bodies are kept in
+// Body, anything else in Text.
+type Response struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	Text   string          `json:"text,omitempty"`
+}
+
+// Fixture maps URL paths (e.g. `/api/models`) to recorded responses.
+// Fixtures created by Record carry RecordedAt and Recorder, fixtures written
+// by hand explain their origin in Note.
+type Fixture struct {
+	SQLMeshVersion string              `json:"sqlmesh_version"`
+	RecordedAt     string              `json:"recorded_at,omitempty"`
+	Recorder       string              `json:"recorder,omitempty"`
+	Note           string              `json:"note,omitempty"`
+	Responses      map[string]Response `json:"responses"`
+}
+
+// Recorded reports if the fixture was captured from a running SQLMesh UI.
+func (f *Fixture) Recorded() bool {
+	return f.RecordedAt != "" && f.Recorder != ""
+}
+
+// Fixtures lists names of the fixtures embedded in the package.
+func Fixtures() []string {
+	entries, err := fixturesFS.ReadDir("fixtures")
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadEmbeddedFixture loads one of the fixtures listed by Fixtures.
+func LoadEmbeddedFixture(name string) (*Fixture, error) {
+	content, err := fixturesFS.ReadFile(path.Join("fixtures", name+".json"))
+	if err != nil {
+		return nil, err
+	}
+	return decodeFixture(name, content)
+}
+
+// LoadFixture loads a fixture stored with Save.
+func LoadFixture(filename string) (*Fixture, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return decodeFixture(filename, content)
+}
+
+func decodeFixture(name string, content []byte) (*Fixture, error) {
+	fixture := &Fixture{}
+	if err := json.Unmarshal(content, fixture); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if fixture.Responses == nil {
+		fixture.Responses = map[string]Response{}
+	}
+	return fixture, nil
+}
+
+func (f *Fixture) Save(filename string) error {
+	asJson, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, asJson, 0644)
+}
@@ -0,0 +1,88 @@
+package sqlmeshtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/getsynq/synq-sqlmesh/build"
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+)
+
+// Record captures responses of a running SQLMesh UI for every endpoint used
+// by `sqlmesh.CollectMetadata`, including content of the files accepted by
+// the filter.
+func Record(baseUrl url.URL, fileContentGlobFilter sqlmesh.GlobFilter) (*Fixture, error) {
+	fixture := &Fixture{
+		RecordedAt: time.Now().UTC().Format(time.RFC3339),
+		Recorder:   strings.TrimSpace(fmt.Sprintf("synq-sqlmesh/%s", build.Version)),
+		Responses:  map[string]Response{},
+	}
+
+	record := func(body json.RawMessage, err error, prefix []string, path ...string) json.RawMessage {
+		key := (&url.URL{Path: "/"}).JoinPath(prefix...).JoinPath(path...).Path
+		var apiErr *sqlmesh.SQLMeshApiError
+		if err == nil {
+			fixture.Responses[key] = newResponse(200, body)
+		} else if errors.As(err, &apiErr) {
+			fixture.Responses[key] = newResponse(apiErr.Code, []byte(apiErr.Message))
+		}
+		return body
+	}
+
+	api := sqlmesh.NewAPIClient(baseUrl)
+	meta, err := api.GetMeta()
+	var apiErr *sqlmesh.SQLMeshApiError
+	if err != nil && !errors.As(err, &apiErr) {
+		return nil, err
+	}
+	record(meta, err, []string{"api", "meta"})
+	if version, err := sqlmesh.VersionFromMeta(meta); err == nil {
+		fixture.SQLMeshVersion = version.Raw
+	}
+
+	health, err := api.Health()
+	record(health, err, []string{"health"})
+
+	models, err := api.GetModels()
+	record(models, err, []string{"api", "models"})
+	modelNames, _ := sqlmesh.ModelNames(models)
+	for _, modelName := range modelNames {
+		body, err := api.GetModel(modelName)
+		record(body, err, []string{"api", "models"}, modelName)
+		body, err = api.GetLineage(modelName)
+		record(body, err, []string{"api", "lineage"}, modelName)
+	}
+
+	files, err := api.GetFiles()
+	record(files, err, []string{"api", "files"})
+	dir := sqlmesh.Directory{}
+	if len(files) > 0 && json.Unmarshal(files, &dir) == nil {
+		dirsToProcess := []sqlmesh.Directory{dir}
+		for len(dirsToProcess) > 0 {
+			dir := dirsToProcess[0]
+			dirsToProcess = append(dirsToProcess[1:], dir.Directories...)
+			for _, file := range dir.Files {
+				if accepted, _ := fileContentGlobFilter.Match(file.Path); accepted {
+					body, err := api.GetFileContent(file.Path)
+					record(body, err, []string{"api", "files"}, file.Path)
+				}
+			}
+		}
+	}
+
+	environments, err := api.GetEnvironments()
+	record(environments, err, []string{"api", "environments"})
+
+	return fixture, nil
+}
+
+func newResponse(status int, body []byte) Response {
+	if json.Valid(body) {
+		return Response{Status: status, Body: append(json.RawMessage{}, body...)}
+	}
+	return Response{Status: status, Text: string(body)}
+}
@@ -0,0 +1,47 @@
+package sqlmeshtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+)
+
+// NewServer starts a fake SQLMesh UI replaying the fixture. Callers must
+// Close it, its base URL can be passed to `sqlmesh.CollectMetadata`.
+func NewServer(fixture *Fixture) *httptest.Server {
+	return httptest.NewServer(Handler(fixture))
+}
+
+// BaseUrl returns the server URL in the form used by the `sqlmesh` package.
+func BaseUrl(server *httptest.Server) url.URL {
+	u, _ := url.Parse(server.URL)
+	return *u
+}
+
+// Handler serves recorded responses, unknown paths are answered with 404
+// the same way SQLMesh UI does.
+func Handler(fixture *Fixture) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := fixture.Responses[r.URL.Path]
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"detail":"Not Found"}`))
+			return
+		}
+
+		status := response.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if len(response.Body) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write(response.Body)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response.Text))
+	})
+}
@@ -0,0 +1,172 @@
+package sqlmesh
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	ingestsqlmeshv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/sqlmesh/v1"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityOff     Severity = "off"
+)
+
+const (
+	RuleEmptyDetails       = "empty-details"
+	RuleUnknownLineage     = "unknown-lineage"
+	RuleMissingOwner       = "missing-owner"
+	RuleMissingDescription = "missing-description"
+	RuleOrphanFile         = "orphan-file"
+	RuleApiError           = "api-error"
+	RuleDecodeError        = "decode-error"
+)
+
+// DefaultValidationRules are the severities used for rules which are not
+// configured explicitly.
+var DefaultValidationRules = map[string]Severity{
+	RuleEmptyDetails:       SeverityError,
+	RuleUnknownLineage:     SeverityError,
+	RuleMissingOwner:       SeverityWarning,
+	RuleMissingDescription: SeverityWarning,
+	RuleOrphanFile:         SeverityWarning,
+	RuleApiError:           SeverityError,
+	RuleDecodeError:        SeverityError,
+}
+
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Subject  string   `json:"subject"`
+	Message  string   `json:"message"`
+}
+
+// ParseValidationRules parses `rule=severity` pairs on top of
+// DefaultValidationRules.
+func ParseValidationRules(specs []string) (map[string]Severity, error) {
+	rules := map[string]Severity{}
+	for rule, severity := range DefaultValidationRules {
+		rules[rule] = severity
+	}
+	for _, spec := range specs {
+		rule, severity, ok := strings.Cut(strings.TrimSpace(spec), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule %s, expected <rule>=error|warning|off", spec)
+		}
+		if _, known := DefaultValidationRules[rule]; !known {
+			return nil, fmt.Errorf("unknown rule %s", rule)
+		}
+		switch Severity(severity) {
+		case SeverityError, SeverityWarning, SeverityOff:
+			rules[rule] = Severity(severity)
+		default:
+			return nil, fmt.Errorf("invalid severity %s of rule %s, expected error, warning or off", severity, rule)
+		}
+	}
+	return rules, nil
+}
+
+// Validate checks the collected metadata for problems which make the
+// snapshot incomplete. Findings are sorted by rule and subject, rules with
+// severity off are skipped.
+func Validate(req *ingestsqlmeshv1.IngestMetadataRequest, rules map[string]Severity) []Finding {
+	var findings []Finding
+	report := func(rule string, subject string, format string, args ...interface{}) {
+		severity, ok := rules[rule]
+		if !ok {
+			severity = DefaultValidationRules[rule]
+		}
+		if severity == SeverityOff {
+			return
+		}
+		findings = append(findings, Finding{
+			Rule:     rule,
+			Severity: severity,
+			Subject:  subject,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	metadata, errs := DecodeMetadata(req)
+	for _, err := range errs {
+		report(RuleDecodeError, "", "%s", err)
+	}
+
+	for _, apiErr := range req.Errors {
+		report(RuleApiError, apiErr.GetPath(), "HTTP %d: %s", apiErr.GetCode(), apiErr.GetMessage())
+	}
+
+	for _, modelName := range sortedKeys(metadata.Models) {
+		model := metadata.Models[modelName]
+		external := model.Type.String() == "external"
+
+		if len(req.ModelDetails[modelName]) == 0 {
+			report(RuleEmptyDetails, modelName, "model details were not collected")
+		} else if model.Details == nil && !external {
+			report(RuleEmptyDetails, modelName, "model details are empty")
+		}
+
+		// external models are declared outside of the project
+		if external {
+			continue
+		}
+		if model.Owner() == "" {
+			report(RuleMissingOwner, modelName, "model has no owner")
+		}
+		if model.Description == "" {
+			report(RuleMissingDescription, modelName, "model has no description")
+		}
+	}
+
+	for _, modelName := range sortedKeys(metadata.Lineage) {
+		if _, ok := metadata.Models[modelName]; !ok {
+			report(RuleUnknownLineage, modelName, "lineage references unknown model")
+		}
+		for _, dep := range metadata.Lineage[modelName] {
+			if _, ok := metadata.Models[dep]; !ok {
+				report(RuleUnknownLineage, modelName, "depends on unknown model %s", dep)
+			}
+		}
+	}
+
+	if metadata.Files != nil {
+		modelPaths := map[string]bool{}
+		for _, model := range metadata.Models {
+			modelPaths[model.Path] = true
+		}
+		for _, filePath := range modelFiles(metadata.Files) {
+			if !modelPaths[filePath] {
+				report(RuleOrphanFile, filePath, "no model is defined by this file")
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Rule != findings[j].Rule {
+			return findings[i].Rule < findings[j].Rule
+		}
+		return findings[i].Subject < findings[j].Subject
+	})
+	return findings
+}
+
+// modelFiles lists SQL and Python files under the `models` directory.
+func modelFiles(dir *Directory) []string {
+	var res []string
+	for _, filePath := range dir.FilePaths() {
+		if !strings.HasPrefix(filePath, "models/") {
+			continue
+		}
+		switch path.Ext(filePath) {
+		case ".sql", ".py":
+			res = append(res, filePath)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,223 @@
+package sqlmesh_test
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
+	"github.com/getsynq/synq-sqlmesh/sqlmesh/sqlmeshtest"
+)
+
+// editResponse changes the JSON object served by the fixture at the path.
+func editResponse(t *testing.T, fixture *sqlmeshtest.Fixture, path string, edit func(body map[string]interface{})) {
+	t.Helper()
+	response := fixture.Responses[path]
+	body := map[string]interface{}{}
+	if err := json.Unmarshal(response.Body, &body); err != nil {
+		t.Fatal(err)
+	}
+	edit(body)
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body = raw
+	fixture.Responses[path] = response
+}
+
+func setModelDetail(key string, value interface{}) func(body map[string]interface{}) {
+	return func(body map[string]interface{}) {
+		body["details"].(map[string]interface{})[key] = value
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		fixture  func(t *testing.T, fixture *sqlmeshtest.Fixture)
+		expected []string
+	}{
+		{
+			name:    "empty-details pass",
+			rule:    sqlmesh.RuleEmptyDetails,
+			fixture: func(t *testing.T, fixture *sqlmeshtest.Fixture) {},
+		},
+		{
+			name: "empty-details not collected",
+			rule: sqlmesh.RuleEmptyDetails,
+			fixture: func(t *testing.T, fixture *sqlmeshtest.Fixture) {
+				fixture.Responses["/api/models/example.orders"] = sqlmeshtest.Response{Status: 500, Text: "Internal Server Error"}
+			},
+			expected: []string{"example.orders: model details were not collected"},
+		},
+		{
+			name: "empty-details without details",
+			rule: sqlmesh.RuleEmptyDetails,
+			fixture: func(t *testing.T, fixture *sqlmeshtest.Fixture) {
+				editResponse(t, fixture, "/api/models/example.orders", func(body map[string]interface{}) {
+					body["details"] = nil
+				})
+			},
+			expected: []string{"example.orders: model details are empty"},
+		},
+		{
+			name:    "unknown-lineage pass",
+			rule:    sqlmesh.RuleUnknownLineage,
+			fixture: func(t *testing.T, fixture *sqlmeshtest.Fixture) {},
+		},
+		{
+			name: "unknown-lineage fail",
+			rule: sqlmesh.RuleUnknownLineage,
+			fixture: func(t *testing.T, fixture *sqlmeshtest.Fixture) {
+				editResponse(t, fixture, "/api/lineage/example.orders", func(body map[string]interface{}) {
+					body[`"db"."example"."orders"`] = []string{`"db"."example"."raw_orders"`, `"db"."example"."refunds"`}
+					body[`"db"."example"."refunds"`] = []string{}
+				})
+			},
+			expected: []string{
+				`"db"."example"."refunds": lineage references unknown model`,
+				`example.orders: depends on unknown model "db"."example"."refunds"`,
+			},
+		},
+		{
+			name: "missing-owner pass",
+			rule: sqlmesh.RuleMissingOwner,
+			fixture: func(t *testing.T, fixture *sqlmeshtest.Fixture) {
+				editResponse(t, fixture, "/api/models/example.customer_revenue", setModelDetail("owner", "finance"))
+			},
+		},
+		{
+			name:     "missing-owner fail",
+			rule:     sqlmesh.RuleMissingOwner,
+			fixture:  func(t *testing.T, fixture *sqlmeshtest.Fixture) {},
+			expected: []string{"example.customer_revenue: model has no owner"},
+		},
+		{
+			name: "missing-description pass",
+			rule: sqlmesh.RuleMissingDescription,
+			fixture: func(t *testing.T, fixture *sqlmeshtest.Fixture) {
+				editResponse(t, fixture, "/api/models/example.customer_revenue", func(body map[string]interface{}) {
+					body["description"] = "Revenue per customer and day"
+				})
+			},
+		},
+		{
+			name:     "missing-description fail",
+			rule:     sqlmesh.RuleMissingDescription,
+			fixture:  func(t *testing.T, fixture *sqlmeshtest.Fixture) {},
+			expected: []string{"example.customer_revenue: model has no description"},
+		},
+		{
+			name:    "orphan-file pass",
+			rule:    sqlmesh.RuleOrphanFile,
+			fixture: func(t *testing.T, fixture *sqlmeshtest.Fixture) {},
+		},
+		{
+			name: "orphan-file fail",
+			rule: sqlmesh.RuleOrphanFile,
+			fixture: func(t *testing.T, fixture *sqlmeshtest.Fixture) {
+				editResponse(t, fixture, "/api/files", func(body map[string]interface{}) {
+					models := body["directories"].([]interface{})[1].(map[string]interface{})
+					models["files"] = append(models["files"].([]interface{}), map[string]interface{}{
+						"name": "stale.sql", "path": "models/stale.sql", "extension": ".sql",
+					}, map[string]interface{}{
+						"name": "README.md", "path": "models/README.md", "extension": ".md",
+					})
+				})
+			},
+			expected: []string{"models/stale.sql: no model is defined by this file"},
+		},
+		{
+			name:    "api-error pass",
+			rule:    sqlmesh.RuleApiError,
+			fixture: func(t *testing.T, fixture *sqlmeshtest.Fixture) {},
+		},
+		{
+			name: "api-error fail",
+			rule: sqlmesh.RuleApiError,
+			fixture: func(t *testing.T, fixture *sqlmeshtest.Fixture) {
+				fixture.Responses["/api/environments"] = sqlmeshtest.Response{Status: 500, Text: "Internal Server Error"}
+			},
+			expected: []string{"/api/environments: HTTP 500: Internal Server Error"},
+		},
+		{
+			name:    "decode-error pass",
+			rule:    sqlmesh.RuleDecodeError,
+			fixture: func(t *testing.T, fixture *sqlmeshtest.Fixture) {},
+		},
+		{
+			name: "decode-error fail",
+			rule: sqlmesh.RuleDecodeError,
+			fixture: func(t *testing.T, fixture *sqlmeshtest.Fixture) {
+				response := fixture.Responses["/api/lineage/example.orders"]
+				response.Body = json.RawMessage(`["example.raw_orders"]`)
+				fixture.Responses["/api/lineage/example.orders"] = response
+			},
+			expected: []string{": lineage of example.orders"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixture, baseUrl := startFixtureServer(t, "sqlmesh-0.96")
+			tt.fixture(t, fixture)
+			req, err := sqlmesh.CollectMetadata(baseUrl, sqlmesh.NewExcludeEverythingGlobFilter())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, finding := range sqlmesh.Validate(req, nil) {
+				if finding.Rule != tt.rule {
+					continue
+				}
+				if finding.Severity != sqlmesh.DefaultValidationRules[tt.rule] {
+					t.Errorf("expected severity %s, got %s", sqlmesh.DefaultValidationRules[tt.rule], finding.Severity)
+				}
+				subject := finding.Subject
+				// API errors are reported for full URLs
+				if parsed, err := url.Parse(subject); err == nil && parsed.Host != "" {
+					subject = parsed.Path
+				}
+				// wording of decoding errors depends on the Go version
+				message, _, _ := strings.Cut(finding.Message, ": json: ")
+				got = append(got, subject+": "+message)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateSeverityOverrides(t *testing.T) {
+	_, baseUrl := startFixtureServer(t, "sqlmesh-0.96")
+	req, err := sqlmesh.CollectMetadata(baseUrl, sqlmesh.NewExcludeEverythingGlobFilter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := sqlmesh.ParseValidationRules([]string{"missing-owner=error", "missing-description=off"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []sqlmesh.Finding{{
+		Rule:     sqlmesh.RuleMissingOwner,
+		Severity: sqlmesh.SeverityError,
+		Subject:  "example.customer_revenue",
+		Message:  "model has no owner",
+	}}
+	if got := sqlmesh.Validate(req, rules); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestParseValidationRulesErrors(t *testing.T) {
+	for _, spec := range []string{"missing-owner", "unknown=error", "missing-owner=fatal"} {
+		if _, err := sqlmesh.ParseValidationRules([]string{spec}); err == nil {
+			t.Errorf("expected error for %s", spec)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package sqlmesh
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// MinSupportedVersion is the oldest SQLMesh version synq-sqlmesh was tested with.
+var MinSupportedVersion = Version{Major: 0, Minor: 96}
+
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	Raw   string
+}
+
+var versionRe = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseVersion extracts the first `major.minor[.patch]` version from the
+// text, e.g. output of `sqlmesh --version`.
+func ParseVersion(s string) (Version, error) {
+	m := versionRe.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("no version found in %q", s)
+	}
+	v := Version{Raw: m[0]}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	return v, nil
+}
+
+func (v Version) Compare(other Version) int {
+	switch {
+	case v.Major != other.Major:
+		return v.Major - other.Major
+	case v.Minor != other.Minor:
+		return v.Minor - other.Minor
+	default:
+		return v.Patch - other.Patch
+	}
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
@@ -0,0 +1,66 @@
+package synq
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// CheckToken exchanges the long-lived token at `/oauth2/token` of the
+// endpoint without uploading anything.
+func CheckToken(endpoint string, token string, uploadOpts ...UploadOpt) error {
+	options := newUploadOptions(uploadOpts)
+	parsedEndpoint, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := options.tlsConfig()
+	if err != nil {
+		return err
+	}
+	_, err = obtainToken(parsedEndpoint, token, options.httpClient(tlsConfig))
+	return err
+}
+
+// CheckEndpoint verifies a gRPC connection to the endpoint can be
+// established within the timeout.
+func CheckEndpoint(ctx context.Context, endpoint string, timeout time.Duration, uploadOpts ...UploadOpt) error {
+	options := newUploadOptions(uploadOpts)
+	parsedEndpoint, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := options.tlsConfig()
+	if err != nil {
+		return err
+	}
+	creds := credentials.NewTLS(tlsConfig)
+	if IsInsecureEndpoint(parsedEndpoint) {
+		creds = insecure.NewCredentials()
+	}
+
+	conn, err := grpc.NewClient(grpcEndpoint(parsedEndpoint), grpc.WithTransportCredentials(creds), grpc.WithAuthority(parsedEndpoint.Host))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("could not connect to %s within %s, last state %s", grpcEndpoint(parsedEndpoint), timeout, state)
+		}
+	}
+}
@@ -1,23 +1,38 @@
 package synq
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	ingestsqlmeshv1grpc "buf.build/gen/go/getsynq/api/grpc/go/synq/ingest/sqlmesh/v1/sqlmeshv1grpc"
+	ingestgitv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/git/v1"
 	ingestsqlmeshv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/sqlmesh/v1"
+	"github.com/getsynq/synq-sqlmesh/git"
+	"github.com/getsynq/synq-sqlmesh/sqlmesh"
 	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcgzip "google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+var tracer = otel.Tracer("github.com/getsynq/synq-sqlmesh/synq")
+
 type GitContextDump struct {
 	CloneUrl  string `json:"clone_url"`
 	Branch    string `json:"branch"`
@@ -37,9 +52,55 @@ type IngestMetadataRequestDump struct {
 	StateAt           time.Time                  `json:"state_at"`
 	GitContext        *GitContextDump            `json:"git_context"`
 	Errors            []json.RawMessage          `json:"errors"`
+	ProjectConfig     *sqlmesh.ProjectConfig     `json:"project_config,omitempty"`
+	SQLMeshVersion    string                     `json:"sqlmesh_version,omitempty"`
+	GitDetails        *git.Details               `json:"git_details,omitempty"`
+	FileHistory       map[string]*git.FileCommit `json:"file_history,omitempty"`
+	ImpactReport      *sqlmesh.ImpactReport      `json:"impact_report,omitempty"`
+}
+
+type DumpOpt func(*IngestMetadataRequestDump)
+
+// WithProjectConfig stores summary of the SQLMesh project configuration in
+// the dump.
+func WithProjectConfig(projectConfig *sqlmesh.ProjectConfig) DumpOpt {
+	return func(d *IngestMetadataRequestDump) {
+		d.ProjectConfig = projectConfig
+	}
+}
+
+// WithSQLMeshVersion stores the detected SQLMesh version in the dump.
+func WithSQLMeshVersion(version sqlmesh.Version) DumpOpt {
+	return func(d *IngestMetadataRequestDump) {
+		if version != (sqlmesh.Version{}) {
+			d.SQLMeshVersion = version.String()
+		}
+	}
+}
+
+// WithGitDetails stores details of the checked out code which are not part
+// of GitContext.
+func WithGitDetails(details *git.Details) DumpOpt {
+	return func(d *IngestMetadataRequestDump) {
+		d.GitDetails = details
+	}
 }
 
-func DumpMetadata(output *ingestsqlmeshv1.IngestMetadataRequest, filename string) error {
+// WithFileHistory stores the last commit of project files.
+func WithFileHistory(history map[string]*git.FileCommit) DumpOpt {
+	return func(d *IngestMetadataRequestDump) {
+		d.FileHistory = history
+	}
+}
+
+// WithImpactReport marks models collected because of changed files.
+func WithImpactReport(report *sqlmesh.ImpactReport) DumpOpt {
+	return func(d *IngestMetadataRequestDump) {
+		d.ImpactReport = report
+	}
+}
+
+func DumpMetadata(output *ingestsqlmeshv1.IngestMetadataRequest, filename string, opts ...DumpOpt) error {
 	outputRaw := IngestMetadataRequestDump{
 		ApiMeta:           output.ApiMeta,
 		Models:            output.Models,
@@ -64,6 +125,26 @@ func DumpMetadata(output *ingestsqlmeshv1.IngestMetadataRequest, filename string
 		}
 	}
 
+	for _, opt := range opts {
+		opt(&outputRaw)
+	}
+
+	if isGzipFile(filename) {
+		asJson, err := json.Marshal(outputRaw)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(asJson); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		return os.WriteFile(filename, buf.Bytes(), 0644)
+	}
+
 	asJson, err := json.MarshalIndent(outputRaw, "", "  ")
 	if err != nil {
 		return err
@@ -72,74 +153,211 @@ func DumpMetadata(output *ingestsqlmeshv1.IngestMetadataRequest, filename string
 	return os.WriteFile(filename, asJson, 0644)
 }
 
-func UploadMetadata(ctx context.Context, output *ingestsqlmeshv1.IngestMetadataRequest, endpoint string, token string) error {
+// LoadMetadata reads a file written by DumpMetadata back into a request.
+// Files ending with `.gz` are transparently decompressed.
+func LoadMetadata(filename string) (*ingestsqlmeshv1.IngestMetadataRequest, error) {
+	content, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var outputRaw IngestMetadataRequestDump
+	if err := json.Unmarshal(content, &outputRaw); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	toBytes := func(v json.RawMessage, k string) []byte { return v }
+	output := &ingestsqlmeshv1.IngestMetadataRequest{
+		ApiMeta:           outputRaw.ApiMeta,
+		Models:            outputRaw.Models,
+		ModelDetails:      lo.MapValues(outputRaw.ModelDetails, toBytes),
+		ModelLineage:      lo.MapValues(outputRaw.ModelLineage, toBytes),
+		Files:             outputRaw.Files,
+		Environments:      outputRaw.Environments,
+		FileContent:       lo.MapValues(outputRaw.FileContent, toBytes),
+		UploaderVersion:   outputRaw.UploaderVersion,
+		UploaderBuildTime: outputRaw.UploaderBuildTime,
+		StateAt:           timestamppb.New(outputRaw.StateAt),
+	}
+
+	for _, rawErr := range outputRaw.Errors {
+		apiErr := &ingestsqlmeshv1.IngestMetadataRequest_Error{}
+		if err := protojson.Unmarshal(rawErr, apiErr); err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		output.Errors = append(output.Errors, apiErr)
+	}
+
+	if outputRaw.GitContext != nil {
+		output.GitContext = &ingestgitv1.GitContext{
+			CloneUrl:  outputRaw.GitContext.CloneUrl,
+			Branch:    outputRaw.GitContext.Branch,
+			CommitSha: outputRaw.GitContext.CommitSha,
+		}
+	}
+
+	return output, nil
+}
+
+func readFile(filename string) ([]byte, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if !isGzipFile(filename) {
+		return content, nil
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	defer gz.Close()
+	return io.ReadAll(gz)
+}
+
+func isGzipFile(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(filename), ".gz")
+}
+
+type UploadOpt func(*uploadOptions)
+
+type uploadOptions struct {
+	compression    bool
+	caFile         string
+	clientCertFile string
+	clientKeyFile  string
+}
+
+// WithCompression toggles gzip compression of the gRPC requests, it is
+// enabled by default.
+func WithCompression(enabled bool) UploadOpt {
+	return func(o *uploadOptions) {
+		o.compression = enabled
+	}
+}
+
+func newUploadOptions(opts []UploadOpt) *uploadOptions {
+	o := &uploadOptions{
+		compression: true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func dial(ctx context.Context, endpoint string, token string, uploadOpts ...UploadOpt) (*grpc.ClientConn, error) {
+	options := newUploadOptions(uploadOpts)
+
 	parsedEndpoint, err := url.Parse(endpoint)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	oauthTokenSource, err := LongLivedTokenSource(token, parsedEndpoint)
+	tlsConfig, err := options.tlsConfig()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	_, tokenSpan := tracer.Start(ctx, "TokenExchange", trace.WithSpanKind(trace.SpanKindClient))
+	oauthTokenSource, err := LongLivedTokenSource(token, parsedEndpoint, options.httpClient(tlsConfig))
+	endSpan(tokenSpan, err)
+	if err != nil {
+		return nil, err
+	}
+	// gRPC resolves HTTPS_PROXY/NO_PROXY from the environment on its own.
+	creds := credentials.NewTLS(tlsConfig)
+	if IsInsecureEndpoint(parsedEndpoint) {
+		logrus.Warnf("!!! SYNQ API endpoint %s uses plaintext HTTP, token and metadata are sent UNENCRYPTED. Use only for local testing !!!", parsedEndpoint.String())
+		creds = insecure.NewCredentials()
+		oauthTokenSource = InsecureTokenSource(oauthTokenSource)
 	}
-	creds := credentials.NewTLS(&tls.Config{InsecureSkipVerify: false})
 	opts := []grpc.DialOption{
 		grpc.WithTransportCredentials(creds),
 		grpc.WithPerRPCCredentials(oauthTokenSource),
 		grpc.WithAuthority(parsedEndpoint.Host),
 	}
+	if options.compression {
+		opts = append(opts, grpc.WithDefaultCallOptions(grpc.UseCompressor(grpcgzip.Name)))
+	}
 
-	conn, err := grpc.DialContext(ctx, grpcEndpoint(parsedEndpoint), opts...)
+	return grpc.DialContext(ctx, grpcEndpoint(parsedEndpoint), opts...)
+}
+
+func UploadMetadata(ctx context.Context, output *ingestsqlmeshv1.IngestMetadataRequest, endpoint string, token string, opts ...UploadOpt) error {
+	ctx, span := tracer.Start(ctx, "IngestMetadata", trace.WithSpanKind(trace.SpanKindClient))
+	span.SetAttributes(attribute.String("rpc.system", "grpc"), attribute.String("server.address", endpoint))
+	conn, err := dial(ctx, endpoint, token, opts...)
 	if err != nil {
+		endSpan(span, err)
 		return err
 	}
 	defer conn.Close()
 
+	start := time.Now()
 	sqlMeshServiceClient := ingestsqlmeshv1grpc.NewSqlMeshServiceClient(conn)
 	resp, err := sqlMeshServiceClient.IngestMetadata(ctx, output)
+	endSpan(span, err)
+	log := logrus.WithFields(logrus.Fields{
+		"endpoint":    endpoint,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
 	if err != nil {
+		log.WithError(err).Debug("SYNQ API request failed")
 		return err
 	}
-	logrus.Infof("Metadata uploaded successfully: %s", resp.String())
+	log.Infof("Metadata uploaded successfully: %s", resp.String())
 	return nil
 }
 
-func UploadExecutionLog(ctx context.Context, output *ingestsqlmeshv1.IngestExecutionRequest, endpoint string, token string) error {
-	parsedEndpoint, err := url.Parse(endpoint)
-	if err != nil {
-		return err
-	}
-
-	oauthTokenSource, err := LongLivedTokenSource(token, parsedEndpoint)
-	if err != nil {
-		return err
-	}
-	creds := credentials.NewTLS(&tls.Config{InsecureSkipVerify: false})
-	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(creds),
-		grpc.WithPerRPCCredentials(oauthTokenSource),
-		grpc.WithAuthority(parsedEndpoint.Host),
-	}
-
-	conn, err := grpc.DialContext(ctx, grpcEndpoint(parsedEndpoint), opts...)
+func UploadExecutionLog(ctx context.Context, output *ingestsqlmeshv1.IngestExecutionRequest, endpoint string, token string, opts ...UploadOpt) error {
+	ctx, span := tracer.Start(ctx, "IngestExecution", trace.WithSpanKind(trace.SpanKindClient))
+	span.SetAttributes(attribute.String("rpc.system", "grpc"), attribute.String("server.address", endpoint))
+	conn, err := dial(ctx, endpoint, token, opts...)
 	if err != nil {
+		endSpan(span, err)
 		return err
 	}
 	defer conn.Close()
 
+	start := time.Now()
 	sqlMeshServiceClient := ingestsqlmeshv1grpc.NewSqlMeshServiceClient(conn)
 	resp, err := sqlMeshServiceClient.IngestExecution(ctx, output)
+	endSpan(span, err)
+	log := logrus.WithFields(logrus.Fields{
+		"endpoint":    endpoint,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
 	if err != nil {
+		log.WithError(err).Debug("SYNQ API request failed")
 		return err
 	}
-	logrus.Infof("Logs uploaded successfully: %s", resp.String())
+	log.Infof("Logs uploaded successfully: %s", resp.String())
 	return nil
 }
 
+// endSpan ends the span, marking it failed on error.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// IsInsecureEndpoint reports if the endpoint should be reached without TLS,
+// which is the case for `http://` URLs, e.g. a local stand-in of SYNQ API.
+func IsInsecureEndpoint(endpoint *url.URL) bool {
+	return endpoint.Scheme == "http"
+}
+
 func grpcEndpoint(endpoint *url.URL) string {
 	port := endpoint.Port()
 	if port == "" {
 		port = "443"
+		if IsInsecureEndpoint(endpoint) {
+			port = "80"
+		}
 	}
 	return fmt.Sprintf("%s:%s", endpoint.Hostname(), port)
 }
@@ -0,0 +1,124 @@
+package synq_test
+
+import (
+	"context"
+	"testing"
+
+	ingestsqlmeshv1 "buf.build/gen/go/getsynq/api/protocolbuffers/go/synq/ingest/sqlmesh/v1"
+	"github.com/getsynq/synq-sqlmesh/devserver"
+	"github.com/getsynq/synq-sqlmesh/synq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func startDevServer(t *testing.T, opts devserver.Options) *devserver.Server {
+	t.Helper()
+	server := devserver.New(opts)
+	if err := server.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	return server
+}
+
+func metadataRequest() *ingestsqlmeshv1.IngestMetadataRequest {
+	return &ingestsqlmeshv1.IngestMetadataRequest{
+		ApiMeta:         []byte(`{"version":"0.96.1"}`),
+		Models:          []byte(`[{"name":"example.orders"}]`),
+		ModelDetails:    map[string][]byte{"example.orders": []byte(`{"name":"example.orders"}`)},
+		UploaderVersion: "test",
+		StateAt:         timestamppb.Now(),
+	}
+}
+
+func TestUploadMetadata(t *testing.T) {
+	server := startDevServer(t, devserver.Options{Token: "st-test"})
+
+	if err := synq.UploadMetadata(context.Background(), metadataRequest(), server.URL(), "st-test"); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := server.MetadataRequests()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 metadata request, got %d", len(requests))
+	}
+	if got := string(requests[0].GetModels()); got != `[{"name":"example.orders"}]` {
+		t.Errorf("unexpected models %s", got)
+	}
+	if got := string(requests[0].GetModelDetails()["example.orders"]); got != `{"name":"example.orders"}` {
+		t.Errorf("unexpected model details %s", got)
+	}
+	if encodings := server.Encodings(); len(encodings) != 1 || encodings[0] != "gzip" {
+		t.Errorf("expected a gzip compressed call, got %v", encodings)
+	}
+}
+
+func TestUploadMetadataWithoutCompression(t *testing.T) {
+	server := startDevServer(t, devserver.Options{})
+
+	if err := synq.UploadMetadata(context.Background(), metadataRequest(), server.URL(), "st-test", synq.WithCompression(false)); err != nil {
+		t.Fatal(err)
+	}
+	if encodings := server.Encodings(); len(encodings) != 1 || encodings[0] != "" {
+		t.Errorf("expected an uncompressed call, got %v", encodings)
+	}
+}
+
+func TestUploadExecutionLog(t *testing.T) {
+	server := startDevServer(t, devserver.Options{})
+
+	req := &ingestsqlmeshv1.IngestExecutionRequest{
+		Command:    []string{"sqlmesh", "run"},
+		StdOut:     []byte("Run finished"),
+		StartedAt:  timestamppb.Now(),
+		FinishedAt: timestamppb.Now(),
+	}
+	if err := synq.UploadExecutionLog(context.Background(), req, server.URL(), "st-test"); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := server.ExecutionRequests()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 execution request, got %d", len(requests))
+	}
+	if got := string(requests[0].GetStdOut()); got != "Run finished" {
+		t.Errorf("unexpected stdout %q", got)
+	}
+	if encodings := server.Encodings(); len(encodings) != 1 || encodings[0] != "gzip" {
+		t.Errorf("expected a gzip compressed call, got %v", encodings)
+	}
+}
+
+func TestUploadRejectedToken(t *testing.T) {
+	for name, opts := range map[string]devserver.Options{
+		"rejected": {RejectToken: true},
+		"unknown":  {Token: "st-other"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := startDevServer(t, opts)
+
+			if err := synq.UploadMetadata(context.Background(), metadataRequest(), server.URL(), "st-test"); err == nil {
+				t.Fatal("expected token exchange to fail")
+			}
+			if requests := server.MetadataRequests(); len(requests) != 0 {
+				t.Errorf("expected no recorded requests, got %d", len(requests))
+			}
+		})
+	}
+}
+
+func TestUploadFailFirst(t *testing.T) {
+	server := startDevServer(t, devserver.Options{FailFirst: 1, FailCode: codes.ResourceExhausted})
+
+	err := synq.UploadMetadata(context.Background(), metadataRequest(), server.URL(), "st-test")
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected injected ResourceExhausted, got %v", err)
+	}
+	if err := synq.UploadMetadata(context.Background(), metadataRequest(), server.URL(), "st-test"); err != nil {
+		t.Fatal(err)
+	}
+	if requests := server.MetadataRequests(); len(requests) != 1 {
+		t.Errorf("expected 1 recorded request, got %d", len(requests))
+	}
+}
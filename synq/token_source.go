@@ -2,10 +2,12 @@ package synq
 
 import (
 	"context"
+	"net/http"
+	"net/url"
+
 	"golang.org/x/oauth2"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/oauth"
-	"net/url"
 )
 
 type TokenSource interface {
@@ -13,25 +15,26 @@ type TokenSource interface {
 	credentials.PerRPCCredentials
 }
 
-func LongLivedTokenSource(longLivedToken string, apiEndpoint *url.URL) (TokenSource, error) {
-	initialToken, err := obtainToken(apiEndpoint, longLivedToken)
+func LongLivedTokenSource(longLivedToken string, apiEndpoint *url.URL, httpClient *http.Client) (TokenSource, error) {
+	initialToken, err := obtainToken(apiEndpoint, longLivedToken, httpClient)
 	if err != nil {
 		return nil, err
 	}
 
-	return oauth.TokenSource{TokenSource: oauth2.ReuseTokenSource(initialToken, &tokenSource{apiEndpoint: apiEndpoint, longLivedToken: longLivedToken})}, nil
+	return oauth.TokenSource{TokenSource: oauth2.ReuseTokenSource(initialToken, &tokenSource{apiEndpoint: apiEndpoint, longLivedToken: longLivedToken, httpClient: httpClient})}, nil
 }
 
 type tokenSource struct {
 	longLivedToken string
 	apiEndpoint    *url.URL
+	httpClient     *http.Client
 }
 
 func (t *tokenSource) Token() (*oauth2.Token, error) {
-	return obtainToken(t.apiEndpoint, t.longLivedToken)
+	return obtainToken(t.apiEndpoint, t.longLivedToken, t.httpClient)
 }
 
-func obtainToken(apiEndpoint *url.URL, longLivedToken string) (*oauth2.Token, error) {
+func obtainToken(apiEndpoint *url.URL, longLivedToken string, httpClient *http.Client) (*oauth2.Token, error) {
 
 	tokenURL, _ := url.Parse(apiEndpoint.String())
 	tokenURL.Path = "/oauth2/token"
@@ -41,5 +44,33 @@ func obtainToken(apiEndpoint *url.URL, longLivedToken string) (*oauth2.Token, er
 			AuthStyle: oauth2.AuthStyleInParams,
 		},
 	}
-	return conf.PasswordCredentialsToken(context.Background(), "synq", longLivedToken)
+	ctx := context.Background()
+	if httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	}
+	return conf.PasswordCredentialsToken(ctx, "synq", longLivedToken)
+}
+
+// InsecureTokenSource allows the token to be attached to requests sent over
+// plaintext connections, it must only be used with local endpoints.
+func InsecureTokenSource(ts TokenSource) TokenSource {
+	return &insecureTokenSource{TokenSource: ts}
+}
+
+type insecureTokenSource struct {
+	TokenSource
+}
+
+func (t *insecureTokenSource) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := t.Token()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"authorization": token.Type() + " " + token.AccessToken,
+	}, nil
+}
+
+func (t *insecureTokenSource) RequireTransportSecurity() bool {
+	return false
 }
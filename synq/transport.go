@@ -0,0 +1,73 @@
+package synq
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// WithCAFile adds certificates from the PEM encoded file to the system
+// roots used to verify SYNQ API, useful behind TLS-inspecting proxies.
+func WithCAFile(caFile string) UploadOpt {
+	return func(o *uploadOptions) {
+		o.caFile = caFile
+	}
+}
+
+// WithClientCertificate presents the given PEM encoded certificate and key
+// to the server (mTLS).
+func WithClientCertificate(certFile string, keyFile string) UploadOpt {
+	return func(o *uploadOptions) {
+		o.clientCertFile = certFile
+		o.clientKeyFile = keyFile
+	}
+}
+
+func (o *uploadOptions) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: false}
+
+	if o.caFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		caContent, err := os.ReadFile(o.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(caContent) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file %s", o.caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if o.clientCertFile != "" || o.clientKeyFile != "" {
+		if o.clientCertFile == "" || o.clientKeyFile == "" {
+			return nil, fmt.Errorf("both client certificate and client key have to be provided")
+		}
+		cert, err := tls.LoadX509KeyPair(o.clientCertFile, o.clientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// httpClient returns the client used for the OAuth token exchange. It shares
+// TLS settings with the gRPC connection and, like gRPC, resolves the proxy
+// from HTTPS_PROXY/HTTP_PROXY/NO_PROXY.
+func (o *uploadOptions) httpClient(tlsConfig *tls.Config) *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}